@@ -0,0 +1,169 @@
+// Package main is the account administration tool for the Creature Sighting server.
+// It manages the user accounts and API tokens in the server's accounts file:
+//
+//	authctl [-users users.json] add-user -name NAME -role ROLE   (password on stdin)
+//	authctl [-users users.json] remove-user -name NAME
+//	authctl [-users users.json] users
+//	authctl [-users users.json] issue-token -user NAME [-label LABEL] [-quota N]
+//	authctl [-users users.json] revoke-token -id ID
+//	authctl [-users users.json] tokens
+//
+// A running server rereads the file when it changes, so there is no need to restart it.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/auth"
+)
+
+// main runs the requested command and exits non-zero on failure.
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "authctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags and dispatches to a command.
+func run(args []string) error {
+	global := flag.NewFlagSet("authctl", flag.ContinueOnError)
+	usersPath := global.String("users", "users.json", "accounts file")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	if global.NArg() == 0 {
+		return errors.New("missing command: add-user, remove-user, users, issue-token, revoke-token or tokens")
+	}
+
+	store, err := auth.Open(*usersPath)
+	if err != nil {
+		return err
+	}
+
+	command, rest := global.Arg(0), global.Args()[1:]
+	switch command {
+	case "add-user":
+		return addUser(store, rest)
+	case "remove-user":
+		return removeUser(store, rest)
+	case "users":
+		return listUsers(store)
+	case "issue-token":
+		return issueToken(store, rest)
+	case "revoke-token":
+		return revokeToken(store, rest)
+	case "tokens":
+		return listTokens(store)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// addUser creates an account, reading its password from the first line of stdin.
+func addUser(store *auth.Store, args []string) error {
+	fs := flag.NewFlagSet("add-user", flag.ContinueOnError)
+	name := fs.String("name", "", "username")
+	role := fs.String("role", string(auth.RoleViewer), "viewer, field-operative, analyst or admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	parsed, err := auth.ParseRole(*role)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	if err := store.AddUser(*name, parsed, password); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s (%s)\n", *name, parsed)
+	return nil
+}
+
+// removeUser deletes an account and its tokens.
+func removeUser(store *auth.Store, args []string) error {
+	fs := flag.NewFlagSet("remove-user", flag.ContinueOnError)
+	name := fs.String("name", "", "username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := store.RemoveUser(*name); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", *name)
+	return nil
+}
+
+// listUsers prints every account and its role.
+func listUsers(store *auth.Store) error {
+	for _, u := range store.Users() {
+		fmt.Printf("%-32s %s\n", u.Name, u.Role)
+	}
+	return nil
+}
+
+// issueToken creates an API token and prints its secret, which is not stored.
+func issueToken(store *auth.Store, args []string) error {
+	fs := flag.NewFlagSet("issue-token", flag.ContinueOnError)
+	user := fs.String("user", "", "account the token acts as")
+	label := fs.String("label", "", "note describing what the token is for")
+	quota := fs.Int("quota", 0, "requests per day, or 0 for the server's default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	secret, token, err := store.IssueToken(*user, *label, *quota)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Issued token %s for %s; it will not be shown again.\n", token.ID, token.User)
+	fmt.Println(secret)
+	return nil
+}
+
+// revokeToken revokes a token so the server no longer accepts it.
+func revokeToken(store *auth.Store, args []string) error {
+	fs := flag.NewFlagSet("revoke-token", flag.ContinueOnError)
+	id := fs.String("id", "", "token ID, as listed by tokens")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	token, err := store.RevokeToken(*id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Revoked token %s for %s\n", token.ID, token.User)
+	return nil
+}
+
+// listTokens prints every issued token without its secret.
+func listTokens(store *auth.Store) error {
+	for _, t := range store.Tokens() {
+		state := "active"
+		if !t.Active() {
+			state = "revoked"
+		}
+		quota := "default"
+		if t.DailyQuota > 0 {
+			quota = fmt.Sprint(t.DailyQuota)
+		}
+		fmt.Printf("%s  %-32s %-7s %s  quota %-8s %s\n", t.ID, t.User, state, t.Created.Format("2006-01-02 15:04"), quota, t.Label)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Package main is an example generator plugin for the Creature Sighting server.
+// It speaks the line-delimited JSON protocol described in internal/plugin:
+// it reads one request per line on stdin and writes one response per line on stdout.
+// Build it into the server's plugin directory to register the "phantom" category.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/plugin"
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// names and types are the phantom catalog.
+var (
+	names = []string{"Pale Wanderer", "Lantern Widow", "Hollow Drummer", "Grey Ferryman"}
+	types = []string{"Apparition", "Poltergeist", "Will-o'-the-wisp"}
+)
+
+// main answers requests until stdin is closed.
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+
+	for scanner.Scan() {
+		var req plugin.Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			respond(encoder, plugin.Response{Error: fmt.Sprintf("bad request: %v", err)})
+			continue
+		}
+
+		switch req.Method {
+		case plugin.MethodDescribe:
+			respond(encoder, plugin.Response{Category: &sighting.CategoryInfo{
+				Name:        "phantom",
+				DisplayName: "Phantom",
+				Description: "Incorporeal entities, negligible physical threat",
+				Criteria:    "Entities with no measurable mass that are observed by two or more independent instruments.",
+				Types:       types,
+			}})
+		case plugin.MethodGenerate:
+			var opts sighting.Options
+			if req.Options != nil {
+				opts = *req.Options
+			}
+			respond(encoder, plugin.Response{Sighting: generate(opts)})
+		default:
+			respond(encoder, plugin.Response{Error: fmt.Sprintf("unknown method %q", req.Method)})
+		}
+	}
+}
+
+// generate builds a phantom sighting, honouring the requested type, location and seed.
+func generate(opts sighting.Options) *sighting.Sighting {
+	rng := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+	if opts.Seed != nil {
+		rng = rand.New(rand.NewPCG(uint64(*opts.Seed), 0))
+	}
+
+	phantomType := opts.Type
+	if phantomType == "" {
+		phantomType = types[rng.IntN(len(types))]
+	}
+
+	loc := sighting.Location{Latitude: 53.3498, Longitude: -6.2603, City: "Dublin", Country: "Ireland", Region: "Europe"}
+	if opts.Location != nil {
+		loc = *opts.Location
+	}
+
+	name := names[rng.IntN(len(names))]
+	return &sighting.Sighting{
+		Name:        name,
+		Type:        phantomType,
+		Location:    loc,
+		Description: fmt.Sprintf("A flickering %s drifting through %s", phantomType, loc.City),
+		Timestamp:   opts.Timestamp,
+	}
+}
+
+// respond writes a single response line, exiting if stdout is gone.
+func respond(encoder *json.Encoder, resp plugin.Response) {
+	if err := encoder.Encode(resp); err != nil {
+		log.Fatalf("write response: %v", err)
+	}
+}
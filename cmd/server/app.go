@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/creatures/cryptid"
+	"github.com/pymk/creature-sighting/internal/creatures/kaiju"
+	"github.com/pymk/creature-sighting/internal/creatures/marine"
+	"github.com/pymk/creature-sighting/internal/creatures/ufo"
+	"github.com/pymk/creature-sighting/internal/plugin"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/storage"
+)
+
+// app is the generator registry and storage shared by every command.
+type app struct {
+	registry *sighting.Registry
+	store    *storage.InMemoryStorage
+	plugins  []*plugin.Plugin
+	builtin  []string // built-in categories, which get demo sightings
+}
+
+// appFlags are the flags every command accepts for building the app.
+type appFlags struct {
+	pluginDir     *string
+	pluginTimeout *time.Duration
+}
+
+// addAppFlags registers the flags for building the app on fs.
+func addAppFlags(fs *flag.FlagSet) appFlags {
+	return appFlags{
+		pluginDir:     fs.String("plugins", "", "directory of generator plugin executables"),
+		pluginTimeout: fs.Duration("plugin-timeout", 5*time.Second, "maximum time a plugin may take per request"),
+	}
+}
+
+// newApp registers the built-in generators and any plugins with a new registry
+// and creates an empty storage. Close the app to stop its plugins.
+func newApp(flags appFlags) (*app, error) {
+	a := &app{
+		registry: sighting.NewRegistry(),
+		store:    storage.NewInMemoryStorage(),
+	}
+
+	kaijuGen := kaiju.NewGenerator()
+	if err := a.registry.Register("kaiju", kaijuGen); err != nil {
+		return nil, err
+	}
+
+	cryptidGen := cryptid.NewGenerator()
+	if err := a.registry.Register("cryptid", cryptidGen); err != nil {
+		return nil, err
+	}
+
+	ufoGen := ufo.NewGenerator()
+	if err := a.registry.Register("ufo", ufoGen); err != nil {
+		return nil, err
+	}
+
+	marineGen := marine.NewGenerator()
+	if err := a.registry.Register("marine", marineGen); err != nil {
+		return nil, err
+	}
+
+	a.builtin = []string{kaijuGen.Category(), cryptidGen.Category(), ufoGen.Category(), marineGen.Category()}
+
+	// Register external generators under the category each plugin declares
+	if *flags.pluginDir != "" {
+		plugins, err := plugin.Discover(*flags.pluginDir, *flags.pluginTimeout)
+		if err != nil {
+			return nil, err
+		}
+		a.plugins = plugins
+		for _, p := range plugins {
+			if err := a.registry.Register(p.Category(), p); err != nil {
+				a.Close()
+				return nil, err
+			}
+			log.Printf("Registered plugin category %s", p.Category())
+		}
+	}
+
+	// Keep generated names unique against stored sightings. Cryptids and UFOs
+	// are not named by a namer: their names repeat by design
+	kaijuGen.SetNameCheck(a.store.HasName)
+	marineGen.SetNameCheck(a.store.HasName)
+
+	return a, nil
+}
+
+// load fills storage from the file at dataPath. Without a file, storage gets
+// demo sightings if demo is set and is otherwise left empty.
+func (a *app) load(dataPath string, demo bool) error {
+	if dataPath != "" {
+		n, err := a.store.Load(dataPath)
+		if err == nil {
+			log.Printf("Loaded %d sightings from %s", n, dataPath)
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if demo {
+		a.store.GenerateInitialSightings(a.registry, a.builtin...)
+	}
+	return nil
+}
+
+// Close stops the app's plugins.
+func (a *app) Close() {
+	for _, p := range a.plugins {
+		p.Close()
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// generate prints freshly generated sightings of one category as a JSON array.
+// With -seed, sighting i is generated with seed+i, so the same seed always gives
+// the same creatures; add -at to fix their timestamp and weather too.
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	common := addAppFlags(flags)
+	category := flags.String("category", "", "category to generate, as listed by the categories command")
+	count := flags.Int("count", 1, "number of sightings to generate")
+	seed := flags.Int64("seed", 0, "seed for reproducible output; sighting i uses seed+i")
+	at := flags.String("at", "", "timestamp for every sighting, as RFC 3339, instead of the current time")
+	flags.Parse(args)
+
+	seeded := false
+	flags.Visit(func(f *flag.Flag) {
+		seeded = seeded || f.Name == "seed"
+	})
+	if *count < 1 {
+		return fmt.Errorf("invalid count %d: must be at least 1", *count)
+	}
+	var timestamp time.Time
+	if *at != "" {
+		var err error
+		if timestamp, err = time.Parse(time.RFC3339, *at); err != nil {
+			return fmt.Errorf("invalid -at timestamp: %w", err)
+		}
+	}
+
+	a, err := newApp(common)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	generator, err := a.registry.GetContext(*category)
+	if err != nil {
+		return err
+	}
+
+	sightings := make([]sighting.Sighting, 0, *count)
+	for i := range *count {
+		opts := sighting.Options{Timestamp: timestamp}
+		if seeded {
+			n := *seed + int64(i)
+			opts.Seed = &n
+		}
+
+		s, err := generator.GenerateWithOptions(context.Background(), opts)
+		if err != nil {
+			return fmt.Errorf("generate sighting %d: %w", i+1, err)
+		}
+		if err := a.registry.Normalize(s); err != nil {
+			return fmt.Errorf("generate sighting %d: %w", i+1, err)
+		}
+		// Store the batch so generated names stay unique within it
+		a.store.Add(*s)
+		sightings = append(sightings, *s)
+	}
+
+	return writeJSON(os.Stdout, sightings)
+}
+
+// export prints the sightings in a storage file as a JSON array, oldest first.
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	common := addAppFlags(flags)
+	dataPath := flags.String("data", "", "storage file to export, as written by serve -data")
+	flags.Parse(args)
+
+	if *dataPath == "" {
+		return errors.New("missing -data storage file")
+	}
+
+	a, err := newApp(common)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if _, err := a.store.Load(*dataPath); err != nil {
+		return err
+	}
+	sightings := a.store.GetAll()
+	slices.Reverse(sightings)
+	return writeJSON(os.Stdout, sightings)
+}
+
+// importSightings adds the sightings in a JSON array, read from the named file or
+// stdin, to a storage file, creating it if necessary. Every sighting is validated
+// against its category before anything is saved, and sightings whose ID is
+// already stored are skipped. The server must not be running on the same file,
+// since it overwrites the file when it shuts down.
+func importSightings(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	common := addAppFlags(flags)
+	dataPath := flags.String("data", "", "storage file to import into, as read by serve -data")
+	flags.Parse(args)
+
+	if *dataPath == "" {
+		return errors.New("missing -data storage file")
+	}
+
+	var in io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var sightings []sighting.Sighting
+	if err := json.NewDecoder(in).Decode(&sightings); err != nil {
+		return fmt.Errorf("invalid JSON sightings: %w", err)
+	}
+
+	a, err := newApp(common)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.load(*dataPath, false); err != nil {
+		return err
+	}
+
+	added, skipped := 0, 0
+	for i := range sightings {
+		s := &sightings[i]
+		if s.ID == "" {
+			return fmt.Errorf("sighting %d has no ID", i+1)
+		}
+		if _, exists := a.store.Get(s.ID); exists {
+			skipped++
+			continue
+		}
+		if err := a.registry.Normalize(s); err != nil {
+			return fmt.Errorf("sighting %s: %w", s.ID, err)
+		}
+		a.store.Add(*s)
+		added++
+	}
+
+	if err := a.store.Save(*dataPath); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d sightings into %s (%d already present)\n", added, *dataPath, skipped)
+	return nil
+}
+
+// listCategories prints every registered category with its types.
+func listCategories(args []string) error {
+	flags := flag.NewFlagSet("categories", flag.ExitOnError)
+	common := addAppFlags(flags)
+	flags.Parse(args)
+
+	a, err := newApp(common)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tNAME\tTYPES")
+	for _, info := range a.registry.Infos() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", info.Name, info.DisplayName, strings.Join(info.Types, ", "))
+	}
+	return w.Flush()
+}
+
+// stats prints how many sightings the server would start with, by category and
+// status, along with their witness reports.
+func stats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	common := addAppFlags(flags)
+	dataPath := flags.String("data", "", "storage file to report on; demo sightings are used if it is not given or does not exist")
+	flags.Parse(args)
+
+	a, err := newApp(common)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.load(*dataPath, true); err != nil {
+		return err
+	}
+
+	counts := make(map[string]map[sighting.Status]int)
+	reports := make(map[string]int)
+	for _, s := range a.store.GetAll() {
+		if counts[s.Category] == nil {
+			counts[s.Category] = make(map[sighting.Status]int)
+		}
+		counts[s.Category][s.Status]++
+		reports[s.Category] += len(s.Reports)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "CATEGORY\tTOTAL\t")
+	for _, status := range sighting.Statuses {
+		fmt.Fprintf(w, "%s\t", strings.ToUpper(string(status)))
+	}
+	fmt.Fprintln(w, "REPORTS\t")
+
+	all := make(map[sighting.Status]int)
+	total, totalReports := 0, 0
+	for _, category := range a.registry.Categories() {
+		n := 0
+		for status, c := range counts[category] {
+			n += c
+			all[status] += c
+		}
+		writeStatsRow(w, category, n, counts[category], reports[category])
+		total += n
+		totalReports += reports[category]
+	}
+	writeStatsRow(w, "all", total, all, totalReports)
+	return w.Flush()
+}
+
+// writeStatsRow writes one row of the stats table.
+func writeStatsRow(w io.Writer, label string, total int, counts map[sighting.Status]int, reports int) {
+	fmt.Fprintf(w, "%s\t%d\t", label, total)
+	for _, status := range sighting.Statuses {
+		fmt.Fprintf(w, "%d\t", counts[status])
+	}
+	fmt.Fprintf(w, "%d\t\n", reports)
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// update rewrites the golden files from the current generators.
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenSeeds are the seeds each built-in category is generated with.
+var goldenSeeds = []int64{1, 2, 3}
+
+// TestGoldenSightings generates each built-in category with fixed seeds and a fixed
+// time, and compares the sightings, descriptions included, with
+// testdata/<category>.golden.json. Run with -update after changing a generator or
+// grammar on purpose, and review the diff.
+func TestGoldenSightings(t *testing.T) {
+	a, err := newApp(addAppFlags(flag.NewFlagSet("test", flag.ContinueOnError)))
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	t.Cleanup(a.Close)
+	timestamp := time.Date(2024, time.October, 31, 22, 15, 0, 0, time.UTC)
+
+	for _, category := range a.builtin {
+		t.Run(category, func(t *testing.T) {
+			generator, err := a.registry.GetContext(category)
+			if err != nil {
+				t.Fatalf("GetContext: %v", err)
+			}
+
+			var sightings []*sighting.Sighting
+			for _, seed := range goldenSeeds {
+				s, err := generator.GenerateWithOptions(context.Background(), sighting.Options{Seed: &seed, Timestamp: timestamp})
+				if err != nil {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+				s.ID = "" // IDs come from the clock, and report IDs from the sighting's
+				for i := range s.Reports {
+					s.Reports[i].ID = ""
+				}
+				sightings = append(sightings, s)
+			}
+			got, err := json.MarshalIndent(sightings, "", "  ")
+			if err != nil {
+				t.Fatalf("encoding sightings: %v", err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", category+".golden.json")
+			if *update {
+				if err := os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("seeded %s sightings differ from %s; run with -update if the change is intended\ngot:\n%s", category, golden, got)
+			}
+		})
+	}
+}
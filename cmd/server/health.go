@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pymk/creature-sighting/internal/health"
+	"github.com/pymk/creature-sighting/internal/plugin"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/storage"
+)
+
+// registerHealthChecks adds a readiness check for each registered generator, the
+// storage and each plugin's supervised process. Call it after all categories are
+// registered.
+func registerHealthChecks(checker *health.Checker, registry *sighting.Registry, store *storage.InMemoryStorage, plugins []*plugin.Plugin) {
+	for _, category := range registry.Categories() {
+		checker.Add("generator:"+category, func(ctx context.Context) error {
+			return probeGenerator(ctx, registry, category)
+		})
+	}
+
+	checker.Add("storage", store.CheckWritable)
+
+	for _, p := range plugins {
+		checker.Add("plugin:"+p.Category(), func(context.Context) error {
+			return p.Check()
+		})
+	}
+}
+
+// probeGenerator generates and validates a sighting without storing it. The
+// generator is fetched with Get rather than GetContext so that probes are not
+// counted in the generation metrics.
+func probeGenerator(ctx context.Context, registry *sighting.Registry, category string) error {
+	generator, err := registry.Get(category)
+	if err != nil {
+		return err
+	}
+
+	s, err := sighting.Adapt(generator).GenerateWithOptions(ctx, sighting.Options{})
+	if err != nil {
+		return err
+	}
+	return registry.Normalize(s)
+}
@@ -1,77 +1,272 @@
-// Package main provides the HTTP server entry point for the Creature Sighting application.
-// Creature Sighting generates and displays fictional creature sightings.
+// Package main provides the entry point for the Creature Sighting application.
+// Creature Sighting generates and displays fictional creature sightings. With no
+// command, or with only flags, the binary runs the HTTP server:
+//
+//	server [serve] [-plugins DIR] [-data FILE] [flags]
+//	server generate -category NAME [-count N] [-seed N]
+//	server export -data FILE
+//	server import -data FILE [SIGHTINGS.json]
+//	server categories
+//	server stats [-data FILE]
+//
+// Every command accepts -plugins and -plugin-timeout, so plugin categories can
+// be generated, imported and listed too.
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/pymk/creature-sighting/internal/api"
-	"github.com/pymk/creature-sighting/internal/creatures/kaiju"
-	"github.com/pymk/creature-sighting/internal/sighting"
-	"github.com/pymk/creature-sighting/internal/storage"
+	"github.com/pymk/creature-sighting/internal/assets"
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/geofence"
+	"github.com/pymk/creature-sighting/internal/health"
+	"github.com/pymk/creature-sighting/internal/https"
+	"github.com/pymk/creature-sighting/internal/metrics"
+	"github.com/pymk/creature-sighting/internal/middleware"
+	"github.com/pymk/creature-sighting/internal/ratelimit"
+	"github.com/pymk/creature-sighting/internal/templates"
 	"github.com/pymk/creature-sighting/internal/web"
+	"github.com/pymk/creature-sighting/internal/webhook"
+	"github.com/pymk/creature-sighting/static"
 )
 
-// main is the application entry point that starts the HTTP server.
+// main is the application entry point that runs the requested command.
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
 
-// run initializes and starts the HTTP server with graceful shutdown handling.
+// run dispatches to a command. Arguments that start with a flag run the server,
+// so invocations from before commands existed keep working.
+func run(args []string) error {
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		return serve(args)
+	case "generate":
+		return generate(args)
+	case "export":
+		return export(args)
+	case "import":
+		return importSightings(args)
+	case "categories":
+		return listCategories(args)
+	case "stats":
+		return stats(args)
+	default:
+		return fmt.Errorf("unknown command %q: use serve, generate, export, import, categories or stats", command)
+	}
+}
+
+// serve initializes and starts the HTTP server with graceful shutdown handling.
 // It sets up creature generators, storage, handlers, and routes before starting the server.
-func run() error {
-	registry := sighting.NewRegistry()
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	common := addAppFlags(flags)
+	dataPath := flags.String("data", "", "file sightings are loaded from at startup and saved to at shutdown; demo sightings are generated if it does not exist")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	tlsCert := flags.String("tls-cert", "", "PEM certificate file for serving HTTPS, with -tls-key")
+	tlsKey := flags.String("tls-key", "", "PEM private key file for serving HTTPS, with -tls-cert")
+	tlsDev := flags.Bool("tls-dev", false, "serve HTTPS with a self-signed certificate for localhost, generated on first start")
+	tlsDevDir := flags.String("tls-dev-dir", ".devcert", "directory holding the -tls-dev certificate")
+	redirectAddr := flags.String("redirect-addr", "", "address of a plain HTTP listener redirecting to HTTPS, such as :80")
+	hstsMaxAge := flags.Duration("hsts-max-age", 365*24*time.Hour, "how long browsers should insist on HTTPS after a TLS response, or 0 to send no HSTS header")
+	usersPath := flags.String("users", "users.json", "file of user accounts and API tokens, managed with authctl")
+	sessionTTL := flags.Duration("session-ttl", 12*time.Hour, "how long a web sign-in lasts")
+	private := flags.Bool("private", false, "require a signed-in viewer to read sightings")
+	rate := flags.Float64("rate", 5, "sustained API requests per second for each client")
+	burst := flags.Int("burst", 20, "API requests each client may make at once")
+	dailyQuota := flags.Int("daily-quota", 10000, "API requests per day for each API token without its own quota")
+	anonymousQuota := flags.Int("anonymous-quota", 1000, "API requests per day for each IP address without a token")
+	requestTimeout := flags.Duration("request-timeout", 30*time.Second, "maximum time a request may take before the server answers 503")
+	checkTimeout := flags.Duration("check-timeout", 2*time.Second, "maximum time each readiness check may take")
+	checkCache := flags.Duration("check-cache", 5*time.Second, "how long readiness check results are reused before the components are checked again")
+	drainDelay := flags.Duration("drain-delay", 0, "how long to keep serving with readiness failing before shutting down")
+	staticDir := flags.String("static-dir", "", "serve static assets from this directory instead of the embedded copies, for development")
+	webhooksPath := flags.String("webhooks", "webhooks.json", "file of webhook subscriptions, managed through /api/webhooks")
+	webhookAttempts := flags.Int("webhook-attempts", webhook.DefaultConfig.MaxAttempts, "times a webhook delivery is tried before it is dead-lettered")
+	webhookBackoff := flags.Duration("webhook-backoff", webhook.DefaultConfig.Backoff, "wait before the first webhook retry, doubling for each later one")
+	geofencesPath := flags.String("geofences", "geofences.json", "file of geofences, managed through /api/geofences")
+	logFormat := flags.String("log-format", "text", "log output format: text or json")
+	flags.Parse(args)
 
-	kaijuGen := kaiju.NewGenerator()
-	if err := registry.Register("kaiju", kaijuGen); err != nil {
+	// HTTPS uses the given certificate, or a generated one in development
+	switch {
+	case (*tlsCert == "") != (*tlsKey == ""):
+		return errors.New("-tls-cert and -tls-key must be given together")
+	case *tlsDev && *tlsCert != "":
+		return errors.New("-tls-dev cannot be combined with -tls-cert")
+	case *redirectAddr != "" && !*tlsDev && *tlsCert == "":
+		return errors.New("-redirect-addr requires HTTPS: use -tls-cert and -tls-key, or -tls-dev")
+	}
+
+	// Route all logging, including the standard logger, through one structured logger
+	logger, err := newLogger(*logFormat)
+	if err != nil {
 		return err
 	}
+	slog.SetDefault(logger)
 
-	// Initialize storage with some initial sightings
-	store := storage.NewInMemoryStorage()
-	store.GenerateInitialSightings(registry)
+	certFile, keyFile := *tlsCert, *tlsKey
+	if *tlsDev {
+		certFile, keyFile, err = https.DevCertificate(*tlsDevDir)
+		if err != nil {
+			return err
+		}
+	}
+	useTLS := certFile != ""
 
-	// API handlers
-	apiHandler := api.NewHandler(registry)
+	accounts, err := auth.Open(*usersPath)
+	if err != nil {
+		return err
+	}
+	if len(accounts.Users()) == 0 {
+		log.Printf("No user accounts in %s; write operations are unavailable until one is added with authctl", *usersPath)
+	}
+	authn := auth.NewAuthenticator(accounts, auth.NewSessions(*sessionTTL))
 
-	// Web handlers
-	webHandler := web.NewHandler(registry, store)
+	limiter, err := ratelimit.New(ratelimit.Config{
+		Rate:           *rate,
+		Burst:          *burst,
+		DailyQuota:     *dailyQuota,
+		AnonymousQuota: *anonymousQuota,
+	})
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(common)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	registry, store := a.registry, a.store
+
+	// Export generation, storage and request metrics for Prometheus
+	metricsRegistry := metrics.NewRegistry()
+	requestMetrics := metrics.NewHTTP(metricsRegistry)
+	registerMetrics(metricsRegistry, registry, store)
+
+	// Readiness covers every generator, the storage and plugin processes
+	checker := health.NewChecker(*checkTimeout, *checkCache)
+	registerHealthChecks(checker, registry, store, a.plugins)
+
+	webhooks, err := webhook.Open(*webhooksPath, webhook.Config{
+		MaxAttempts: *webhookAttempts,
+		Backoff:     *webhookBackoff,
+	})
+	if err != nil {
+		return err
+	}
+	defer webhooks.Close()
+	checker.Add("webhooks", func(context.Context) error { return webhooks.Check() })
+
+	geofences, err := geofence.Open(*geofencesPath)
+	if err != nil {
+		return err
+	}
+
+	// Initialize storage from the data file, or with some demo sightings
+	if err := a.load(*dataPath, true); err != nil {
+		return err
+	}
+	// Only sightings added from now on are delivered or raise alerts, not the
+	// ones just loaded
+	store.OnAdd(webhooks.Notify)
+	store.OnAdd(geofences.Evaluate)
+
+	// Static assets are embedded unless a directory is given to edit them live
+	var files fs.FS = static.FS
+	if *staticDir != "" {
+		files = os.DirFS(*staticDir)
+	}
+	staticAssets, err := assets.New(files, *staticDir != "")
+	if err != nil {
+		return err
+	}
+	templates.UseAssets(staticAssets.URL)
 
-	mux := http.NewServeMux()
+	// API handlers
+	apiHandler := api.NewHandler(registry, store, accounts, webhooks, geofences)
 
-	// Web routes
-	mux.HandleFunc("/", webHandler.HandleHome)
-	mux.HandleFunc("/sightings", webHandler.HandleSightings)
-	mux.HandleFunc("/sighting/", webHandler.HandleSightingDetail)
-	mux.HandleFunc("/locations", webHandler.HandleLocations)
-	mux.HandleFunc("/categories", webHandler.HandleCategories)
+	// Web handlers
+	webHandler := web.NewHandler(registry, store, authn, webhooks, geofences)
 
-	// API routes
-	mux.HandleFunc("/api/sighting", apiHandler.HandleSighting)
-	mux.HandleFunc("/api/categories", apiHandler.HandleCategories)
+	// Routes, each requiring the role it needs
+	mux := routes{
+		api:            apiHandler,
+		web:            webHandler,
+		authn:          authn,
+		limiter:        limiter,
+		requestMetrics: requestMetrics,
+		metrics:        metricsRegistry,
+		checker:        checker,
+		assets:         staticAssets,
+		private:        *private,
+	}.mux()
 
-	// Static files
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	// Every request gets an ID and an access log line; panics become 500 pages.
+	// Recovery runs inside the timeout so the logged stack is the handler's own.
+	handler := middleware.Chain(mux,
+		middleware.RequestID(),
+		middleware.AccessLog(logger),
+		middleware.Timeout(*requestTimeout),
+		middleware.Recover(logger, http.HandlerFunc(web.ServerError)),
+	)
+	if useTLS && *hstsMaxAge > 0 {
+		handler = middleware.HSTS(*hstsMaxAge)(handler)
+	}
 
+	// HTTP/2 is negotiated automatically when serving TLS
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	serverErr := make(chan error, 1)
+	serverErr := make(chan error, 2)
 	go func() {
-		log.Printf("Server starting on %s", server.Addr)
-		serverErr <- server.ListenAndServe()
+		if useTLS {
+			log.Printf("Server starting on %s with TLS", server.Addr)
+			serverErr <- server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			log.Printf("Server starting on %s", server.Addr)
+			serverErr <- server.ListenAndServe()
+		}
 	}()
 
+	// Optionally send plain HTTP visitors to the HTTPS listener
+	var redirect *http.Server
+	if *redirectAddr != "" {
+		redirect = &http.Server{
+			Addr:              *redirectAddr,
+			Handler:           https.Redirect(*addr),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", redirect.Addr)
+			serverErr <- redirect.ListenAndServe()
+		}()
+	}
+
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -84,8 +279,41 @@ func run() error {
 	case sig := <-sigChan:
 		// Received shutdown signal - perform graceful shutdown
 		log.Printf("Received signal: %v", sig)
+
+		// Fail readiness first so load balancers stop sending new requests
+		checker.Shutdown()
+		if *drainDelay > 0 {
+			log.Printf("Draining for %s before shutting down", *drainDelay)
+			time.Sleep(*drainDelay)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		return server.Shutdown(ctx)
+		if redirect != nil {
+			redirect.Shutdown(ctx)
+		}
+		if err := server.Shutdown(ctx); err != nil {
+			return err
+		}
+
+		if *dataPath != "" {
+			if err := store.Save(*dataPath); err != nil {
+				return err
+			}
+			log.Printf("Saved %d sightings to %s", store.Count(), *dataPath)
+		}
+		return nil
+	}
+}
+
+// newLogger returns a logger writing to stderr in the given format.
+func newLogger(format string) (*slog.Logger, error) {
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, nil)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q: use text or json", format)
 	}
 }
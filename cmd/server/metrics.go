@@ -0,0 +1,55 @@
+package main
+
+import (
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/metrics"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/storage"
+)
+
+// registerMetrics exports the outcome of every generation made through the
+// registry and the contents of storage. Call it after all categories are
+// registered so each starts with zeroed counters.
+func registerMetrics(m *metrics.Registry, registry *sighting.Registry, store *storage.InMemoryStorage) {
+	generated := m.Counter("creature_sightings_generated_total", "Sightings generated, by category.", "category")
+	failed := m.Counter("creature_generation_errors_total", "Generation attempts that failed, by category.", "category")
+	duration := m.Histogram("creature_generation_duration_seconds", "Time taken to generate a sighting, by category.", metrics.DefaultBuckets, "category")
+	for _, category := range registry.Categories() {
+		generated.Add(0, category)
+		failed.Add(0, category)
+	}
+
+	registry.SetObserver(func(category string, elapsed time.Duration, err error) {
+		duration.Observe(elapsed.Seconds(), category)
+		if err != nil {
+			failed.Inc(category)
+			return
+		}
+		generated.Inc(category)
+	})
+
+	m.GaugeFunc("creature_sightings_stored", "Sightings in storage, by category and verification status.", []string{"category", "status"}, func() []metrics.Sample {
+		counts := make(map[[2]string]int)
+		for _, s := range store.GetAll() {
+			counts[[2]string{s.Category, string(s.Status)}]++
+		}
+		samples := make([]metrics.Sample, 0, len(counts))
+		for key, n := range counts {
+			samples = append(samples, metrics.Sample{Labels: key[:], Value: float64(n)})
+		}
+		return samples
+	})
+
+	m.GaugeFunc("creature_witness_reports_stored", "Witness reports in storage, by category of the sighting.", []string{"category"}, func() []metrics.Sample {
+		counts := make(map[string]int)
+		for _, s := range store.GetAll() {
+			counts[s.Category] += len(s.Reports)
+		}
+		samples := make([]metrics.Sample, 0, len(counts))
+		for category, n := range counts {
+			samples = append(samples, metrics.Sample{Labels: []string{category}, Value: float64(n)})
+		}
+		return samples
+	})
+}
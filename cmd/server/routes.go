@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/pymk/creature-sighting/internal/api"
+	"github.com/pymk/creature-sighting/internal/assets"
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/health"
+	"github.com/pymk/creature-sighting/internal/metrics"
+	"github.com/pymk/creature-sighting/internal/ratelimit"
+	"github.com/pymk/creature-sighting/internal/web"
+)
+
+// routes holds the handlers the server's routes dispatch to.
+type routes struct {
+	api            *api.Handler
+	web            *web.Handler
+	authn          *auth.Authenticator
+	limiter        *ratelimit.Limiter
+	requestMetrics *metrics.HTTP
+	metrics        *metrics.Registry
+	checker        *health.Checker
+	assets         *assets.Assets
+	private        bool // reading needs a signed-in viewer
+}
+
+// mux registers every route with the role it requires.
+func (rt routes) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Reading is open to anyone unless the server is private; writes need a role.
+	// Write routes are registered by method and only they reach the handlers that
+	// write, so the read routes sharing their prefix cannot be used to bypass
+	// the role check.
+	read := auth.RoleNone
+	if rt.private {
+		read = auth.RoleViewer
+	}
+	// Each route is timed and counted under its pattern
+	handle := func(pattern string, h http.Handler) {
+		mux.Handle(pattern, rt.requestMetrics.Instrument(pattern, h))
+	}
+	page := rt.authn.RequireUser
+	endpoint := func(required auth.Role, h http.HandlerFunc) http.HandlerFunc {
+		return rt.limiter.LimitIP(rt.authn.RequireToken(required, rt.limiter.Limit(h)))
+	}
+
+	// Web routes
+	handle("/", page(read, rt.web.HandleHome))
+	handle("/sightings", page(read, rt.web.HandleSightings))
+	handle("/sighting/", page(read, rt.web.HandleSightingDetail))
+	handle("/sighting/random", page(auth.RoleFieldOperative, rt.web.HandleRandomSighting))
+	handle("/sighting/new", page(auth.RoleFieldOperative, rt.web.HandleNewSighting))
+	handle("POST /sighting/{id}/reports", page(auth.RoleFieldOperative, rt.web.HandleAddReport))
+	handle("POST /sighting/{id}/status", page(auth.RoleAnalyst, rt.web.HandleChangeStatus))
+	handle("/locations", page(read, rt.web.HandleLocations))
+	handle("/categories", page(read, rt.web.HandleCategories))
+	handle("/category/", page(read, rt.web.HandleCategoryDetail))
+	handle("/alerts", page(read, rt.web.HandleAlerts))
+	handle("/login", page(auth.RoleNone, rt.web.HandleLogin))
+	// Each sign-in attempt costs a password hash, so attempts are throttled per IP
+	handle("POST /login", rt.limiter.LimitIP(page(auth.RoleNone, rt.web.HandleLogin)))
+	handle("/logout", page(auth.RoleNone, rt.web.HandleLogout))
+	handle("/webhooks", page(auth.RoleAdmin, rt.web.HandleWebhooks))
+	handle("POST /webhooks/dead-letters/{id}/retry", page(auth.RoleAdmin, rt.web.HandleWebhookRetry))
+
+	// API routes
+	handle("/api/sighting", endpoint(read, rt.api.HandleSighting))
+	handle("/api/sightings", endpoint(read, rt.api.HandleSightings))
+	handle("/api/sightings/", endpoint(read, rt.api.HandleSightingResource))
+	handle("POST /api/sightings/{id}/reports", endpoint(auth.RoleFieldOperative, rt.api.HandleAddReport))
+	handle("POST /api/sightings/{id}/status", endpoint(auth.RoleAnalyst, rt.api.HandleChangeStatus))
+	handle("/api/categories", endpoint(read, rt.api.HandleCategories))
+	handle("/api/tokens", endpoint(auth.RoleAdmin, rt.api.HandleTokens))
+	handle("/api/tokens/", endpoint(auth.RoleAdmin, rt.api.HandleToken))
+	handle("/api/geofences", endpoint(read, rt.api.HandleGeofences))
+	handle("/api/geofences/", endpoint(read, rt.api.HandleGeofence))
+	handle("POST /api/geofences", endpoint(auth.RoleAnalyst, rt.api.HandleGeofences))
+	handle("PUT /api/geofences/{id}", endpoint(auth.RoleAnalyst, rt.api.HandleGeofence))
+	handle("DELETE /api/geofences/{id}", endpoint(auth.RoleAnalyst, rt.api.HandleGeofence))
+	handle("/api/alerts", endpoint(read, rt.api.HandleAlerts))
+	handle("/api/webhooks", endpoint(auth.RoleAdmin, rt.api.HandleWebhooks))
+	handle("/api/webhooks/", endpoint(auth.RoleAdmin, rt.api.HandleWebhookResource))
+
+	// Metrics are not rate limited so that frequent scrapes are never refused
+	handle("/metrics", rt.authn.RequireToken(read, rt.metrics.ServeHTTP))
+
+	// Probes for orchestrators and load balancers
+	handle("/healthz", http.HandlerFunc(rt.checker.HandleHealthz))
+	handle("/readyz", http.HandlerFunc(rt.checker.HandleReadyz))
+
+	// Static files
+	handle(assets.Prefix, rt.assets)
+
+	return mux
+}
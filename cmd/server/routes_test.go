@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/api"
+	"github.com/pymk/creature-sighting/internal/assets"
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/geofence"
+	"github.com/pymk/creature-sighting/internal/health"
+	"github.com/pymk/creature-sighting/internal/metrics"
+	"github.com/pymk/creature-sighting/internal/ratelimit"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/storage"
+	"github.com/pymk/creature-sighting/internal/web"
+	"github.com/pymk/creature-sighting/internal/webhook"
+	"github.com/pymk/creature-sighting/static"
+)
+
+// testCSRF is the CSRF token sent with every test form, in its cookie and field.
+const testCSRF = "test-csrf-token"
+
+// testServer is the server's real route table over temporary accounts, with an
+// API token and a web session for a user of each role.
+type testServer struct {
+	handler  http.Handler
+	store    *storage.InMemoryStorage
+	tokens   map[auth.Role]string
+	sessions map[auth.Role]*http.Cookie
+}
+
+// newTestServer builds the routes as serve does, backed by files in a temporary
+// directory.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+
+	a, err := newApp(addAppFlags(flag.NewFlagSet("test", flag.ContinueOnError)))
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	t.Cleanup(a.Close)
+
+	accounts, err := auth.Open(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatalf("auth.Open: %v", err)
+	}
+	authn := auth.NewAuthenticator(accounts, auth.NewSessions(time.Hour))
+	ts := &testServer{
+		store:    a.store,
+		tokens:   make(map[auth.Role]string),
+		sessions: make(map[auth.Role]*http.Cookie),
+	}
+	for _, role := range []auth.Role{auth.RoleViewer, auth.RoleFieldOperative, auth.RoleAnalyst} {
+		name := string(role)
+		if err := accounts.AddUser(name, role, "correct horse"); err != nil {
+			t.Fatalf("AddUser: %v", err)
+		}
+		secret, _, err := accounts.IssueToken(name, "test", 0)
+		if err != nil {
+			t.Fatalf("IssueToken: %v", err)
+		}
+		ts.tokens[role] = secret
+
+		u, _ := accounts.User(name)
+		rec := httptest.NewRecorder()
+		authn.SignIn(rec, httptest.NewRequest(http.MethodPost, "/login", nil), u)
+		ts.sessions[role] = rec.Result().Cookies()[0]
+	}
+
+	limiter, err := ratelimit.New(ratelimit.Config{Rate: 1000, Burst: 1000, DailyQuota: 1e6, AnonymousQuota: 1e6})
+	if err != nil {
+		t.Fatalf("ratelimit.New: %v", err)
+	}
+	webhooks, err := webhook.Open(filepath.Join(dir, "webhooks.json"), webhook.Config{})
+	if err != nil {
+		t.Fatalf("webhook.Open: %v", err)
+	}
+	t.Cleanup(webhooks.Close)
+	geofences, err := geofence.Open(filepath.Join(dir, "geofences.json"))
+	if err != nil {
+		t.Fatalf("geofence.Open: %v", err)
+	}
+	staticAssets, err := assets.New(static.FS, false)
+	if err != nil {
+		t.Fatalf("assets.New: %v", err)
+	}
+
+	metricsRegistry := metrics.NewRegistry()
+	ts.handler = routes{
+		api:            api.NewHandler(a.registry, a.store, accounts, webhooks, geofences),
+		web:            web.NewHandler(a.registry, a.store, authn, webhooks, geofences),
+		authn:          authn,
+		limiter:        limiter,
+		requestMetrics: metrics.NewHTTP(metricsRegistry),
+		metrics:        metricsRegistry,
+		checker:        health.NewChecker(time.Second, 0),
+		assets:         staticAssets,
+	}.mux()
+	return ts
+}
+
+// request sends a request for target as a user of the given role, or anonymously
+// for auth.RoleNone. Paths under /api/ use the role's token and other paths its
+// session. POSTs carry a valid witness report or status change for the resource
+// the target names, so only routing and roles can refuse them.
+func (ts *testServer) request(method, target string, role auth.Role) *httptest.ResponseRecorder {
+	api := strings.HasPrefix(target, "/api/")
+	unescaped, _ := url.PathUnescape(target)
+	resource := path.Base(unescaped)
+
+	var body string
+	switch {
+	case method != http.MethodPost:
+	case api && resource == "reports":
+		body = `{"text": "It was huge", "confidence": "high", "distance": 100}`
+	case api && resource == "status":
+		body = `{"status": "under_review", "reason": "Checking the footage"}`
+	case resource == "reports":
+		body = url.Values{"text": {"It was huge"}, "confidence": {"high"}, "distance": {"100"}, "csrf_token": {testCSRF}}.Encode()
+	case resource == "status":
+		body = url.Values{"status": {"under_review"}, "reason": {"Checking the footage"}, "csrf_token": {testCSRF}}.Encode()
+	}
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if api {
+		req.Header.Set("Content-Type", "application/json")
+		if role != auth.RoleNone {
+			req.Header.Set("Authorization", "Bearer "+ts.tokens[role])
+		}
+	} else {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: testCSRF})
+		if role != auth.RoleNone {
+			req.AddCookie(ts.sessions[role])
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	ts.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestRoutesEnforceRoles sends reads and writes through the real route table,
+// including write paths whose slash is escaped so that they do not match the
+// role-checked routes, and checks both the response and whether the sighting
+// changed.
+func TestRoutesEnforceRoles(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		method  string
+		path    string // {id} is replaced by a fresh sighting's ID
+		role    auth.Role
+		want    int
+		written bool
+	}{
+		// API writes need a token with the route's role
+		{"POST", "/api/sightings/{id}/reports", auth.RoleNone, http.StatusUnauthorized, false},
+		{"POST", "/api/sightings/{id}/reports", auth.RoleViewer, http.StatusForbidden, false},
+		{"POST", "/api/sightings/{id}/reports", auth.RoleFieldOperative, http.StatusCreated, true},
+		{"POST", "/api/sightings/{id}/status", auth.RoleFieldOperative, http.StatusForbidden, false},
+		{"POST", "/api/sightings/{id}/status", auth.RoleAnalyst, http.StatusOK, true},
+		{"POST", "/api/sightings/{id}%2Freports", auth.RoleNone, http.StatusMethodNotAllowed, false},
+		{"POST", "/api/sightings/{id}%2freports", auth.RoleViewer, http.StatusMethodNotAllowed, false},
+		{"POST", "/api/sightings/{id}%2Fstatus", auth.RoleNone, http.StatusMethodNotAllowed, false},
+		{"POST", "/api/sightings/{id}%2Fstatus", auth.RoleFieldOperative, http.StatusMethodNotAllowed, false},
+		{"PUT", "/api/sightings/{id}/status", auth.RoleAnalyst, http.StatusMethodNotAllowed, false},
+
+		// API reads are open
+		{"GET", "/api/sightings/{id}/reports", auth.RoleNone, http.StatusOK, false},
+		{"GET", "/api/sightings/{id}/status", auth.RoleNone, http.StatusOK, false},
+
+		// Web writes need a session with the route's role
+		{"POST", "/sighting/{id}/reports", auth.RoleNone, http.StatusSeeOther, false},
+		{"POST", "/sighting/{id}/reports", auth.RoleViewer, http.StatusForbidden, false},
+		{"POST", "/sighting/{id}/reports", auth.RoleFieldOperative, http.StatusSeeOther, true},
+		{"POST", "/sighting/{id}/status", auth.RoleViewer, http.StatusForbidden, false},
+		{"POST", "/sighting/{id}/status", auth.RoleAnalyst, http.StatusSeeOther, true},
+		{"POST", "/sighting/{id}%2Freports", auth.RoleNone, http.StatusMethodNotAllowed, false},
+		{"POST", "/sighting/{id}%2Fstatus", auth.RoleNone, http.StatusMethodNotAllowed, false},
+		{"POST", "/sighting/{id}%2Fstatus", auth.RoleViewer, http.StatusMethodNotAllowed, false},
+
+		// Web reads are open, and escaped slashes name no sighting
+		{"GET", "/sighting/{id}", auth.RoleNone, http.StatusOK, false},
+		{"GET", "/sighting/{id}%2Fstatus", auth.RoleNone, http.StatusNotFound, false},
+	}
+	for i, tt := range tests {
+		role := string(tt.role)
+		if role == "" {
+			role = "anonymous"
+		}
+		t.Run(fmt.Sprintf("%s %s as %s", tt.method, tt.path, role), func(t *testing.T) {
+			id := fmt.Sprintf("kaiju-%d", i)
+			ts.store.Add(sighting.Sighting{ID: id, Name: fmt.Sprintf("Testzilla %d", i), Category: "kaiju"})
+
+			rec := ts.request(tt.method, strings.ReplaceAll(tt.path, "{id}", id), tt.role)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+			}
+
+			s, _ := ts.store.Get(id)
+			if written := len(s.Reports) > 0 || s.Status != sighting.StatusReported; written != tt.written {
+				t.Errorf("sighting changed = %t, want %t", written, tt.written)
+			}
+		})
+	}
+}
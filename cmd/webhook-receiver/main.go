@@ -0,0 +1,76 @@
+// Package main is an example webhook receiver for the Creature Sighting server.
+// It checks the signature of every delivery against the subscription secret and
+// logs the sightings it receives. Start it, then subscribe its URL through
+// /api/webhooks and pass the returned secret with -secret.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/webhook"
+)
+
+// main serves deliveries until interrupted.
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "subscription signing secret; defaults to $WEBHOOK_SECRET")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "how old a delivery's signature may be")
+	fail := flag.Int("fail", 0, "answer 503 to this many deliveries first, to exercise retries")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("A subscription secret is required: pass -secret or set WEBHOOK_SECRET")
+	}
+
+	var failures atomic.Int64
+	failures.Store(int64(*fail))
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		err = webhook.Verify(*secret, r.Header.Get(webhook.TimestampHeader), r.Header.Get(webhook.SignatureHeader), body, *tolerance)
+		if err != nil {
+			log.Printf("Rejected delivery %s: %v", r.Header.Get(webhook.DeliveryHeader), err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if failures.Add(-1) >= 0 {
+			log.Printf("Failing delivery %s on purpose", r.Header.Get(webhook.DeliveryHeader))
+			http.Error(w, "Failing on purpose", http.StatusServiceUnavailable)
+			return
+		}
+
+		var payload struct {
+			Event    string            `json:"event"`
+			Sighting sighting.Sighting `json:"sighting"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+			return
+		}
+		s := payload.Sighting
+		log.Printf("%s %s: %s (%s) in %s, %s, threat %s",
+			r.Header.Get(webhook.DeliveryHeader), payload.Event, s.Name, s.Category,
+			s.Location.City, s.Location.Country, s.Threat.Label())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Receiving webhooks on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/geofence"
+)
+
+// maxGeofenceBody bounds the size of a geofence definition, allowing polygons
+// with the most vertices a geofence may have.
+const maxGeofenceBody = 64 << 10
+
+// geofenceRequest is the body of a POST to /api/geofences or a PUT to
+// /api/geofences/{id}.
+type geofenceRequest struct {
+	Name    string           `json:"name"`
+	Circle  *geofence.Circle `json:"circle"`
+	Polygon []geo.Point      `json:"polygon"`
+}
+
+// HandleGeofences lists and adds geofences via /api/geofences. GET returns every
+// geofence as {"geofences": [...]}; POST accepts a "name" and either a "circle"
+// with a "center" and "radius_km" or a "polygon" of {"lat", "lon"} vertices, and
+// returns the new geofence.
+func (h *Handler) HandleGeofences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		geofences := h.geofences.List()
+		if geofences == nil {
+			geofences = []geofence.Geofence{}
+		}
+		writeJSON(w, http.StatusOK, map[string][]geofence.Geofence{"geofences": geofences})
+
+	case http.MethodPost:
+		g, ok := decodeGeofence(w, r)
+		if !ok {
+			return
+		}
+		var owner string
+		if u, ok := auth.UserFrom(r.Context()); ok {
+			owner = u.Name
+		}
+
+		g, err := h.geofences.Add(g, owner)
+		switch {
+		case errors.Is(err, geofence.ErrInvalidGeofence):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err != nil:
+			log.Printf("Error adding geofence: %v", err)
+			http.Error(w, "Failed to add geofence", http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusCreated, g)
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleGeofence serves a single geofence via /api/geofences/{id}: GET returns it,
+// PUT replaces its name and shape with the same body as a POST to /api/geofences,
+// and DELETE removes it. GET /api/geofences/{id}/alerts returns the alerts it has
+// raised, newest first.
+func (h *Handler) HandleGeofence(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/geofences/")
+	if id, ok := strings.CutSuffix(id, "/alerts"); ok {
+		if _, err := h.geofences.Get(id); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		h.writeAlerts(w, r, id)
+		return
+	}
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var (
+		g   geofence.Geofence
+		err error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		g, err = h.geofences.Get(id)
+	case http.MethodPut:
+		update, ok := decodeGeofence(w, r)
+		if !ok {
+			return
+		}
+		g, err = h.geofences.Update(id, update)
+	case http.MethodDelete:
+		g, err = h.geofences.Remove(id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case errors.Is(err, geofence.ErrNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, geofence.ErrInvalidGeofence):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		log.Printf("Error changing geofence: %v", err)
+		http.Error(w, "Failed to change geofence", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, g)
+	}
+}
+
+// HandleAlerts returns the alerts raised by every geofence via GET /api/alerts,
+// newest first, as {"alerts": [...]}. Accepts an optional "geofence" query
+// parameter naming a geofence ID.
+func (h *Handler) HandleAlerts(w http.ResponseWriter, r *http.Request) {
+	h.writeAlerts(w, r, r.URL.Query().Get("geofence"))
+}
+
+// writeAlerts writes the alerts raised by the geofence with the given ID, or by
+// every geofence if id is empty.
+func (h *Handler) writeAlerts(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	alerts := h.geofences.Alerts(id)
+	if alerts == nil {
+		alerts = []geofence.Alert{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]geofence.Alert{"alerts": alerts})
+}
+
+// decodeGeofence reads a geofence definition from the request body, answering
+// 400 and returning false if it is not valid JSON.
+func decodeGeofence(w http.ResponseWriter, r *http.Request) (geofence.Geofence, bool) {
+	var req geofenceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGeofenceBody)).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON geofence", http.StatusBadRequest)
+		return geofence.Geofence{}, false
+	}
+	return geofence.Geofence{Name: req.Name, Circle: req.Circle, Polygon: req.Polygon}, true
+}
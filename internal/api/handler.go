@@ -4,26 +4,48 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/geofence"
 	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/storage"
+	"github.com/pymk/creature-sighting/internal/webhook"
 )
 
+// maxReportBody bounds the size of a submitted witness report.
+const maxReportBody = 64 << 10
+
 // Handler provides HTTP handlers for API endpoints.
 type Handler struct {
-	registry *sighting.Registry
+	registry  *sighting.Registry
+	storage   *storage.InMemoryStorage
+	accounts  *auth.Store
+	webhooks  *webhook.Dispatcher
+	geofences *geofence.Store
 }
 
-// NewHandler creates a new API handler with the given registry.
-func NewHandler(registry *sighting.Registry) *Handler {
+// NewHandler creates a new API handler with the given registry and storage,
+// issuing API tokens from accounts and managing subscriptions in webhooks and
+// watched areas in geofences.
+func NewHandler(registry *sighting.Registry, storage *storage.InMemoryStorage, accounts *auth.Store, webhooks *webhook.Dispatcher, geofences *geofence.Store) *Handler {
 	return &Handler{
-		registry: registry,
+		registry:  registry,
+		storage:   storage,
+		accounts:  accounts,
+		webhooks:  webhooks,
+		geofences: geofences,
 	}
 }
 
 // HandleSighting generates and returns a random sighting via GET /api/sighting.
-// Accepts optional "category" query parameter, defaults to "kaiju".
+// Accepts optional "category" query parameter, defaults to "kaiju", plus the
+// generation options understood by sighting.ParseOptions (region, type, lat, lon,
+// timestamp, seed).
 func (h *Handler) HandleSighting(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -35,21 +57,36 @@ func (h *Handler) HandleSighting(w http.ResponseWriter, r *http.Request) {
 		category = "kaiju"
 	}
 
-	generator, err := h.registry.Get(category)
+	opts, err := sighting.ParseOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	generator, err := h.registry.GetContext(category)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	sighting, err := generator.Generate()
+	s, err := generator.GenerateWithOptions(r.Context(), opts)
+	if errors.Is(err, sighting.ErrInvalidOptions) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error generating sighting: %v", err)
 		http.Error(w, "Failed to generate sighting", http.StatusInternalServerError)
 		return
 	}
+	if err := h.registry.Normalize(s); err != nil {
+		log.Printf("Error normalizing sighting: %v", err)
+		http.Error(w, "Failed to generate sighting", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(sighting); err != nil {
+	if err := json.NewEncoder(w).Encode(s); err != nil {
 		log.Printf("Error encoding response: %v", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
@@ -57,17 +94,17 @@ func (h *Handler) HandleSighting(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleCategories returns all available creature categories via GET /api/categories.
-// Returns JSON with "categories" array containing all registered category names.
+// Returns JSON with "categories" array containing the metadata of every registered category.
 func (h *Handler) HandleCategories(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	categories := h.registry.Categories()
+	categories := h.registry.Infos()
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string][]string{
+	if err := json.NewEncoder(w).Encode(map[string][]sighting.CategoryInfo{
 		"categories": categories,
 	}); err != nil {
 		log.Printf("Error encoding response: %v", err)
@@ -75,3 +112,202 @@ func (h *Handler) HandleCategories(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// HandleSightings lists stored sightings via GET /api/sightings.
+// Accepts optional "category", "type" and "status" query parameters; every other
+// parameter is an attribute filter such as "behavior=aggressive" or "height_gte=150".
+func (h *Handler) HandleSightings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	category := query.Get("category")
+	sightingType := query.Get("type")
+
+	var status sighting.Status
+	if name := query.Get("status"); name != "" {
+		var err error
+		if status, err = sighting.ParseStatus(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filters := sighting.ParseAttributeFilters(query, "category", "type", "status")
+	if err := h.registry.CheckFilters(category, filters); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var candidates []sighting.Sighting
+	if category != "" {
+		candidates = h.storage.GetByCategory(category)
+	} else {
+		candidates = h.storage.GetAll()
+	}
+
+	schemas := make(map[string]sighting.Schema)
+	for _, info := range h.registry.Infos() {
+		schemas[info.Name] = info.Attributes
+	}
+
+	sightings := make([]sighting.Sighting, 0, len(candidates))
+	for _, s := range candidates {
+		if sightingType != "" && !strings.EqualFold(s.Type, sightingType) {
+			continue
+		}
+		if status != "" && s.Status != status {
+			continue
+		}
+		if !schemas[s.Category].Match(s.Attributes, filters) {
+			continue
+		}
+		sightings = append(sightings, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string][]sighting.Sighting{
+		"sightings": sightings,
+	}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleSightingResource serves a stored sighting's sub-resources via GET
+// /api/sightings/{id}/reports and GET /api/sightings/{id}/status. It only reads:
+// reports and status changes are posted to HandleAddReport and HandleChangeStatus,
+// which are routed by method so that they can require a role.
+func (h *Handler) HandleSightingResource(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, resource, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/sightings/"), "/")
+	if !ok || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch resource {
+	case "reports":
+		h.listReports(w, r, id)
+	case "status":
+		h.showStatus(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// listReports returns a sighting's witness reports as JSON with a "reports" array.
+func (h *Handler) listReports(w http.ResponseWriter, r *http.Request, id string) {
+	reports, exists := h.storage.Reports(id)
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+	if reports == nil {
+		reports = []sighting.Report{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]sighting.Report{"reports": reports})
+}
+
+// HandleAddReport adds a witness report via POST /api/sightings/{id}/reports. It
+// accepts a JSON report with "witness" (omit for anonymous), "distance",
+// "confidence" and "text" and returns the stored report with 201 Created.
+func (h *Handler) HandleAddReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var report sighting.Report
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportBody)).Decode(&report); err != nil {
+		http.Error(w, "Invalid JSON report", http.StatusBadRequest)
+		return
+	}
+	if err := report.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The server assigns identity and timing
+	report.ID, report.Timestamp = "", time.Time{}
+
+	stored, exists := h.storage.AddReport(r.PathValue("id"), report)
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusCreated, stored)
+}
+
+// statusRequest is the body of a POST to /api/sightings/{id}/status.
+type statusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// showStatus returns a sighting's verification "status" and its "history".
+func (h *Handler) showStatus(w http.ResponseWriter, r *http.Request, id string) {
+	s, exists := h.storage.Get(id)
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+	history := s.History
+	if history == nil {
+		history = []sighting.StatusChange{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":  s.Status,
+		"history": history,
+	})
+}
+
+// HandleChangeStatus changes a sighting's verification status via POST
+// /api/sightings/{id}/status. It accepts "status" and "reason", records the
+// token's user as the reviewer and returns the updated sighting.
+func (h *Handler) HandleChangeStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req statusRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportBody)).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON status change", http.StatusBadRequest)
+		return
+	}
+	status, err := sighting.ParseStatus(req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviewer, _ := auth.UserFrom(r.Context())
+	s, err := h.storage.Transition(r.PathValue("id"), status, reviewer.Name, req.Reason)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, sighting.ErrInvalidStatus):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		log.Printf("Error changing status: %v", err)
+		http.Error(w, "Failed to change status", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, s)
+	}
+}
+
+// writeJSON encodes v as the response body with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/auth"
+)
+
+// maxTokenBody bounds the size of a token issuance request.
+const maxTokenBody = 4 << 10
+
+// tokenInfo is an API token as reported to administrators, without its hash.
+type tokenInfo struct {
+	ID         string     `json:"id"`
+	User       string     `json:"user"`
+	Label      string     `json:"label,omitempty"`
+	DailyQuota int        `json:"daily_quota,omitempty"`
+	Created    time.Time  `json:"created"`
+	Revoked    *time.Time `json:"revoked,omitempty"`
+}
+
+// tokenRequest is the body of a POST to /api/tokens.
+type tokenRequest struct {
+	User       string `json:"user"`
+	Label      string `json:"label"`
+	DailyQuota int    `json:"daily_quota"`
+}
+
+// HandleTokens lists and issues API tokens via /api/tokens. GET returns every
+// token, revoked ones included, as {"tokens": [...]}; POST accepts "user", "label"
+// and an optional "daily_quota" and returns the new token with its "secret",
+// which is not shown again.
+func (h *Handler) HandleTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tokens := h.accounts.Tokens()
+		infos := make([]tokenInfo, 0, len(tokens))
+		for _, t := range tokens {
+			infos = append(infos, newTokenInfo(t))
+		}
+		writeJSON(w, http.StatusOK, map[string][]tokenInfo{"tokens": infos})
+
+	case http.MethodPost:
+		var req tokenRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTokenBody)).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON token request", http.StatusBadRequest)
+			return
+		}
+
+		secret, token, err := h.accounts.IssueToken(strings.TrimSpace(req.User), strings.TrimSpace(req.Label), req.DailyQuota)
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound), errors.Is(err, auth.ErrInvalidUser):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err != nil:
+			log.Printf("Error issuing token: %v", err)
+			http.Error(w, "Failed to issue token", http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusCreated, struct {
+				tokenInfo
+				Secret string `json:"secret"`
+			}{newTokenInfo(token), secret})
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleToken revokes an API token via DELETE /api/tokens/{id}, returning the
+// revoked token. Revoking a token twice is harmless.
+func (h *Handler) HandleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/tokens/")
+	token, err := h.accounts.RevokeToken(id)
+	switch {
+	case errors.Is(err, auth.ErrTokenNotFound):
+		http.NotFound(w, r)
+	case err != nil:
+		log.Printf("Error revoking token: %v", err)
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, newTokenInfo(token))
+	}
+}
+
+// newTokenInfo returns the administrator's view of t.
+func newTokenInfo(t auth.Token) tokenInfo {
+	return tokenInfo{
+		ID:         t.ID,
+		User:       t.User,
+		Label:      t.Label,
+		DailyQuota: t.DailyQuota,
+		Created:    t.Created,
+		Revoked:    t.Revoked,
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/webhook"
+)
+
+// maxWebhookBody bounds the size of a webhook subscription request.
+const maxWebhookBody = 16 << 10
+
+// webhookInfo is a webhook subscription as reported to administrators, without
+// its signing secret.
+type webhookInfo struct {
+	ID      string         `json:"id"`
+	URL     string         `json:"url"`
+	Filter  webhook.Filter `json:"filter"`
+	Owner   string         `json:"owner"`
+	Created time.Time      `json:"created"`
+}
+
+// webhookRequest is the body of a POST to /api/webhooks.
+type webhookRequest struct {
+	URL    string         `json:"url"`
+	Filter webhook.Filter `json:"filter"`
+}
+
+// HandleWebhooks lists and adds webhook subscriptions via /api/webhooks. GET
+// returns every subscription as {"webhooks": [...]}; POST accepts a "url" and an
+// optional "filter" of "categories", "regions", "min_threat" and "bbox", and
+// returns the new subscription with its signing "secret", which is not shown
+// again.
+func (h *Handler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		subs := h.webhooks.Subscriptions()
+		infos := make([]webhookInfo, 0, len(subs))
+		for _, sub := range subs {
+			infos = append(infos, newWebhookInfo(sub))
+		}
+		writeJSON(w, http.StatusOK, map[string][]webhookInfo{"webhooks": infos})
+
+	case http.MethodPost:
+		var req webhookRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBody)).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON webhook request", http.StatusBadRequest)
+			return
+		}
+		for _, category := range req.Filter.Categories {
+			if _, err := h.registry.Get(category); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var owner string
+		if u, ok := auth.UserFrom(r.Context()); ok {
+			owner = u.Name
+		}
+		sub, err := h.webhooks.Subscribe(strings.TrimSpace(req.URL), owner, req.Filter)
+		switch {
+		case errors.Is(err, webhook.ErrInvalidSubscription):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err != nil:
+			log.Printf("Error adding webhook: %v", err)
+			http.Error(w, "Failed to add webhook", http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusCreated, struct {
+				webhookInfo
+				Secret string `json:"secret"`
+			}{newWebhookInfo(sub), sub.Secret})
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleWebhookResource routes requests under /api/webhooks/:
+//
+//	DELETE /api/webhooks/{id}                      removes a subscription
+//	GET    /api/webhooks/deliveries                recent deliveries, newest first
+//	GET    /api/webhooks/dead-letters              deliveries that ran out of attempts
+//	POST   /api/webhooks/dead-letters/{id}/retry   queues a dead letter again
+func (h *Handler) HandleWebhookResource(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/webhooks/")
+
+	switch {
+	case path == "deliveries":
+		h.handleDeliveries(w, r, h.webhooks.Deliveries)
+	case path == "dead-letters":
+		h.handleDeliveries(w, r, h.webhooks.DeadLetters)
+	case strings.HasPrefix(path, "dead-letters/"):
+		id, ok := strings.CutSuffix(strings.TrimPrefix(path, "dead-letters/"), "/retry")
+		if !ok || id == "" || strings.Contains(id, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		h.handleRetry(w, r, id)
+	case path != "" && !strings.Contains(path, "/"):
+		h.handleUnsubscribe(w, r, path)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleDeliveries writes the deliveries returned by list as {"deliveries": [...]}.
+func (h *Handler) handleDeliveries(w http.ResponseWriter, r *http.Request, list func() []webhook.Delivery) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]webhook.Delivery{"deliveries": list()})
+}
+
+// handleRetry queues the dead letter with the given ID for redelivery and returns
+// it with 202 Accepted.
+func (h *Handler) handleRetry(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	delivery, err := h.webhooks.Redeliver(id)
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		http.NotFound(w, r)
+	case err != nil:
+		log.Printf("Error redelivering webhook: %v", err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusServiceUnavailable)
+	default:
+		writeJSON(w, http.StatusAccepted, delivery)
+	}
+}
+
+// handleUnsubscribe removes the subscription with the given ID and returns it.
+func (h *Handler) handleUnsubscribe(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sub, err := h.webhooks.Unsubscribe(id)
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		http.NotFound(w, r)
+	case err != nil:
+		log.Printf("Error removing webhook: %v", err)
+		http.Error(w, "Failed to remove webhook", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, newWebhookInfo(sub))
+	}
+}
+
+// newWebhookInfo returns the administrator's view of sub.
+func newWebhookInfo(sub webhook.Subscription) webhookInfo {
+	return webhookInfo{
+		ID:      sub.ID,
+		URL:     sub.URL,
+		Filter:  sub.Filter,
+		Owner:   sub.Owner,
+		Created: sub.Created,
+	}
+}
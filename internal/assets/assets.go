@@ -0,0 +1,125 @@
+// Package assets serves static files under URLs that carry a hash of their
+// content, such as /static/style.3f2a9c1b.css. Because the URL changes whenever
+// the file does, responses to hashed URLs can be cached indefinitely.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Prefix is the URL path under which assets are served.
+const Prefix = "/static/"
+
+// hashLength is the number of hex digits of the content hash put in URLs.
+const hashLength = 8
+
+// hashedName matches a file name carrying a content hash before its extension.
+var hashedName = regexp.MustCompile(`^(.*)\.([0-9a-f]{8})(\.[^./]+)$`)
+
+// Assets resolves and serves the files in a file system.
+type Assets struct {
+	fsys   fs.FS
+	live   bool
+	hashes map[string]string // file name -> content hash, unused when live
+}
+
+// New serves the files in fsys, hashing them once up front. If live is set, as
+// for a directory being edited during development, files are re-hashed on every
+// lookup and served without caching so that changes show up on reload.
+func New(fsys fs.FS, live bool) (*Assets, error) {
+	a := &Assets{
+		fsys:   fsys,
+		live:   live,
+		hashes: make(map[string]string),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		hash, err := a.hash(name)
+		if err != nil {
+			return err
+		}
+		a.hashes[name] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hash static assets: %w", err)
+	}
+	return a, nil
+}
+
+// URL returns the hashed URL for the named asset, such as "style.css". Names
+// that are not assets fall back to their plain URL.
+func (a *Assets) URL(name string) string {
+	hash, ok := a.lookup(name)
+	if !ok {
+		return Prefix + name
+	}
+	ext := path.Ext(name)
+	return Prefix + strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// ServeHTTP serves an asset by its hashed or plain URL. Hashed URLs that match
+// the current content are cached for a year; plain URLs must be revalidated. A
+// hashed URL for content that has since changed is not found, rather than
+// served with the new content under the old hash.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, Prefix)
+	cache := "no-cache"
+	if m := hashedName.FindStringSubmatch(name); m != nil {
+		if hash, ok := a.lookup(m[1] + m[3]); ok {
+			if hash != m[2] && !a.live {
+				http.NotFound(w, r)
+				return
+			}
+			name = m[1] + m[3]
+			if !a.live {
+				cache = "public, max-age=31536000, immutable"
+			}
+		}
+	}
+
+	if _, ok := a.lookup(name); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", cache)
+	http.ServeFileFS(w, r, a.fsys, name)
+}
+
+// lookup returns the content hash of the named asset and whether it exists.
+func (a *Assets) lookup(name string) (string, bool) {
+	if a.live {
+		hash, err := a.hash(name)
+		return hash, err == nil
+	}
+	hash, ok := a.hashes[name]
+	return hash, ok
+}
+
+// hash returns the abbreviated SHA-256 of the named file's content.
+func (a *Assets) hash(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", fs.ErrNotExist
+	}
+	data, err := fs.ReadFile(a.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:hashLength], nil
+}
@@ -0,0 +1,105 @@
+// Package auth authenticates users and decides what they may do. Local accounts
+// sign in to the web interface with a password and receive a session cookie; API
+// clients present a bearer token issued to an account. Every account has a role,
+// and routes declare the least role allowed to use them.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Errors returned by the account store.
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTokenNotFound      = errors.New("token not found")
+	ErrInvalidUser        = errors.New("invalid user")
+	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
+)
+
+// Role is a level of access. Each role may do everything the roles before it in
+// Roles may do.
+type Role string
+
+// Roles, from least to most privileged. RoleNone marks routes open to anyone,
+// including visitors who have not signed in.
+const (
+	RoleNone           Role = ""
+	RoleViewer         Role = "viewer"
+	RoleFieldOperative Role = "field-operative"
+	RoleAnalyst        Role = "analyst"
+	RoleAdmin          Role = "admin"
+)
+
+// Roles lists the roles an account can hold, from least to most privileged.
+var Roles = []Role{RoleViewer, RoleFieldOperative, RoleAnalyst, RoleAdmin}
+
+// usernamePattern restricts usernames to short lowercase identifiers.
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{1,32}$`)
+
+// ParseRole converts a role name to a Role.
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if !slices.Contains(Roles, role) {
+		return "", fmt.Errorf("%w: unknown role %q", ErrInvalidUser, name)
+	}
+	return role, nil
+}
+
+// Allows reports whether an account holding r may use a route that requires the
+// given role.
+func (r Role) Allows(required Role) bool {
+	if required == RoleNone {
+		return true
+	}
+	return slices.Contains(Roles, r) && slices.Index(Roles, r) >= slices.Index(Roles, required)
+}
+
+// User is a local account. Password holds the encoded hash from HashPassword.
+type User struct {
+	Name     string `json:"name"`
+	Role     Role   `json:"role"`
+	Password string `json:"password"`
+}
+
+// Context keys for the signed-in user and the API token they presented.
+type (
+	contextKey      struct{}
+	tokenContextKey struct{}
+)
+
+// WithUser returns a copy of ctx carrying the signed-in user.
+func WithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, contextKey{}, u)
+}
+
+// UserFrom returns the signed-in user carried by ctx, if any.
+func UserFrom(ctx context.Context) (User, bool) {
+	u, ok := ctx.Value(contextKey{}).(User)
+	return u, ok
+}
+
+// WithToken returns a copy of ctx carrying the API token the request presented.
+func WithToken(ctx context.Context, t Token) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, t)
+}
+
+// TokenFrom returns the API token carried by ctx, if any.
+func TokenFrom(ctx context.Context) (Token, bool) {
+	t, ok := ctx.Value(tokenContextKey{}).(Token)
+	return t, ok
+}
+
+// Can reports whether the user carried by ctx may use a route that requires the
+// given role. Visitors who have not signed in may only use open routes.
+func Can(ctx context.Context, required Role) bool {
+	if required == RoleNone {
+		return true
+	}
+	u, ok := UserFrom(ctx)
+	return ok && u.Role.Allows(required)
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Cookie and page names used by the web sign-in flow.
+const (
+	SessionCookie = "session"
+	LoginPath     = "/login"
+)
+
+// Authenticator identifies the user behind a request and enforces the role each
+// route requires. Web routes identify users by session cookie and send visitors
+// who have not signed in to the login page; API routes identify them by bearer
+// token and answer 401 instead.
+type Authenticator struct {
+	store    *Store
+	sessions *Sessions
+}
+
+// NewAuthenticator creates an authenticator backed by the given accounts and sessions.
+func NewAuthenticator(store *Store, sessions *Sessions) *Authenticator {
+	return &Authenticator{
+		store:    store,
+		sessions: sessions,
+	}
+}
+
+// Store returns the accounts the authenticator checks against.
+func (a *Authenticator) Store() *Store {
+	return a.store
+}
+
+// RequireUser wraps a web handler so that it runs only for users holding at least
+// the required role. The signed-in user, if any, is added to the request context
+// even on open routes so pages can show who is signed in.
+func (a *Authenticator) RequireUser(required Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := a.sessionUser(r)
+		if ok {
+			r = r.WithContext(WithUser(r.Context(), u))
+		}
+
+		switch {
+		case u.Role.Allows(required):
+			next(w, r)
+		case !ok:
+			http.Redirect(w, r, LoginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+		default:
+			http.Error(w, fmt.Sprintf("Forbidden: this requires the %s role", required), http.StatusForbidden)
+		}
+	}
+}
+
+// RequireToken wraps an API handler so that it runs only for clients whose bearer
+// token belongs to a user holding at least the required role. A token that is
+// presented but not recognised is rejected even on open routes.
+func (a *Authenticator) RequireToken(required Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, presented := bearerToken(r)
+		u, token, ok := a.store.LookupToken(secret)
+		if ok {
+			r = r.WithContext(WithToken(WithUser(r.Context(), u), token))
+		}
+
+		switch {
+		case presented && !ok:
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Invalid API token", http.StatusUnauthorized)
+		case u.Role.Allows(required):
+			next(w, r)
+		case !ok:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "API token required", http.StatusUnauthorized)
+		default:
+			http.Error(w, fmt.Sprintf("Forbidden: this requires the %s role", required), http.StatusForbidden)
+		}
+	}
+}
+
+// SignIn starts a session for u and sets its cookie on the response.
+func (a *Authenticator) SignIn(w http.ResponseWriter, r *http.Request, u User) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    a.sessions.Create(u.Name),
+		Path:     "/",
+		MaxAge:   int(a.sessions.TTL().Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SignOut ends the request's session, if any, and clears its cookie.
+func (a *Authenticator) SignOut(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(SessionCookie); err == nil {
+		a.sessions.Delete(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// sessionUser returns the account behind the request's session cookie. Accounts
+// removed since the session started are no longer recognised.
+func (a *Authenticator) sessionUser(r *http.Request) (User, bool) {
+	c, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return User{}, false
+	}
+	name, ok := a.sessions.Lookup(c.Value)
+	if !ok {
+		return User{}, false
+	}
+	return a.store.User(name)
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header and
+// whether one was presented.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", true
+	}
+	return strings.TrimSpace(token), true
+}
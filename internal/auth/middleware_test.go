@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixture is an authenticator over a temporary accounts file holding a user of
+// each role, with an API token and a session for each.
+type fixture struct {
+	authn    *Authenticator
+	store    *Store
+	tokens   map[Role]string // token secret by role
+	tokenIDs map[Role]string
+	sessions map[Role]string // session ID by role
+}
+
+// newFixture creates the accounts and signs each user in.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	sessions := NewSessions(time.Hour)
+	f := &fixture{
+		authn:    NewAuthenticator(store, sessions),
+		store:    store,
+		tokens:   make(map[Role]string),
+		tokenIDs: make(map[Role]string),
+		sessions: make(map[Role]string),
+	}
+	for _, role := range Roles {
+		name := string(role)
+		if err := store.AddUser(name, role, "correct horse"); err != nil {
+			t.Fatalf("AddUser: %v", err)
+		}
+		secret, token, err := store.IssueToken(name, "test", 0)
+		if err != nil {
+			t.Fatalf("IssueToken: %v", err)
+		}
+		f.tokens[role], f.tokenIDs[role] = secret, token.ID
+		f.sessions[role] = sessions.Create(name)
+	}
+	return f
+}
+
+// echoUser answers 200 with the name of the user in the request context, if any.
+func echoUser(w http.ResponseWriter, r *http.Request) {
+	if u, ok := UserFrom(r.Context()); ok {
+		w.Write([]byte(u.Name))
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name          string
+		required      Role
+		authorization string
+		want          int
+		wantUser      string
+		wantChallenge string
+	}{
+		{"anonymous on open route", RoleNone, "", http.StatusOK, "", ""},
+		{"anonymous on role route", RoleViewer, "", http.StatusUnauthorized, "", "Bearer"},
+		{"unknown token on open route", RoleNone, "Bearer cs_nope", http.StatusUnauthorized, "", `Bearer error="invalid_token"`},
+		{"other scheme", RoleNone, "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "", `Bearer error="invalid_token"`},
+		{"token on open route", RoleNone, "Bearer " + f.tokens[RoleViewer], http.StatusOK, "viewer", ""},
+		{"exact role", RoleAnalyst, "Bearer " + f.tokens[RoleAnalyst], http.StatusOK, "analyst", ""},
+		{"higher role", RoleFieldOperative, "bearer " + f.tokens[RoleAdmin], http.StatusOK, "admin", ""},
+		{"lower role", RoleAnalyst, "Bearer " + f.tokens[RoleFieldOperative], http.StatusForbidden, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/sightings", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			f.authn.RequireToken(tt.required, echoUser)(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != tt.wantUser {
+				t.Errorf("user in context = %q, want %q", rec.Body.String(), tt.wantUser)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestRequireTokenRevoked(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.store.RevokeToken(f.tokenIDs[RoleAdmin]); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tokens", nil)
+	req.Header.Set("Authorization", "Bearer "+f.tokens[RoleAdmin])
+	rec := httptest.NewRecorder()
+	f.authn.RequireToken(RoleNone, echoUser)(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status for a revoked token = %d, want 401", rec.Code)
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name     string
+		required Role
+		session  string
+		want     int
+		wantUser string
+	}{
+		{"anonymous on open route", RoleNone, "", http.StatusOK, ""},
+		{"anonymous on role route", RoleFieldOperative, "", http.StatusSeeOther, ""},
+		{"unknown session", RoleFieldOperative, "forged", http.StatusSeeOther, ""},
+		{"session on open route", RoleNone, f.sessions[RoleViewer], http.StatusOK, "viewer"},
+		{"exact role", RoleFieldOperative, f.sessions[RoleFieldOperative], http.StatusOK, "field-operative"},
+		{"higher role", RoleAnalyst, f.sessions[RoleAdmin], http.StatusOK, "admin"},
+		{"lower role", RoleAnalyst, f.sessions[RoleViewer], http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/sighting/kaiju-1/status?tab=review", nil)
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.session})
+			}
+			rec := httptest.NewRecorder()
+			f.authn.RequireUser(tt.required, echoUser)(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != tt.wantUser {
+				t.Errorf("user in context = %q, want %q", rec.Body.String(), tt.wantUser)
+			}
+			if tt.want == http.StatusSeeOther {
+				want := LoginPath + "?next=%2Fsighting%2Fkaiju-1%2Fstatus%3Ftab%3Dreview"
+				if got := rec.Header().Get("Location"); got != want {
+					t.Errorf("Location = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireUserRemovedAccount(t *testing.T) {
+	f := newFixture(t)
+	if err := f.store.RemoveUser("analyst"); err != nil {
+		t.Fatalf("RemoveUser: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/sightings", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookie, Value: f.sessions[RoleAnalyst]})
+	rec := httptest.NewRecorder()
+	f.authn.RequireUser(RoleViewer, echoUser)(rec, req)
+
+	if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), LoginPath) {
+		t.Errorf("removed account got %d to %q, want a redirect to sign in", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	for i, held := range Roles {
+		if !held.Allows(RoleNone) {
+			t.Errorf("%s does not allow open routes", held)
+		}
+		for j, required := range Roles {
+			if got, want := held.Allows(required), i >= j; got != want {
+				t.Errorf("%s.Allows(%s) = %t, want %t", held, required, got, want)
+			}
+		}
+	}
+	if RoleNone.Allows(RoleViewer) || Role("superuser").Allows(RoleViewer) {
+		t.Error("an anonymous or unknown role allows a role route")
+	}
+}
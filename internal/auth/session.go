@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// Sessions tracks signed-in web users by an opaque ID kept in a cookie. Sessions
+// live in memory, so restarting the server signs everyone out.
+type Sessions struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]session
+}
+
+// session records who a session belongs to and when it lapses.
+type session struct {
+	user    string
+	expires time.Time
+}
+
+// NewSessions creates a session table whose sessions last for ttl.
+func NewSessions(ttl time.Duration) *Sessions {
+	return &Sessions{
+		ttl:      ttl,
+		sessions: make(map[string]session),
+	}
+}
+
+// Create starts a session for the named user and returns its ID.
+func (s *Sessions) Create(user string) string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	id := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop lapsed sessions so the table does not grow without bound
+	now := time.Now()
+	for k, v := range s.sessions {
+		if now.After(v.expires) {
+			delete(s.sessions, k)
+		}
+	}
+
+	s.sessions[id] = session{user: user, expires: now.Add(s.ttl)}
+	return id
+}
+
+// Lookup returns the user a live session belongs to.
+func (s *Sessions) Lookup(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.sessions[id]
+	if !ok || time.Now().After(v.expires) {
+		delete(s.sessions, id)
+		return "", false
+	}
+	return v.user, true
+}
+
+// Delete ends a session.
+func (s *Sessions) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+}
+
+// TTL returns how long sessions last.
+func (s *Sessions) TTL() time.Duration {
+	return s.ttl
+}
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// tokenPrefix marks API tokens so they are easy to recognise in logs and configs.
+const tokenPrefix = "cs_"
+
+// Token is an API token, or key, issued to an account. Only a hash of the secret
+// is kept; the secret itself is shown once, when the token is issued. DailyQuota
+// overrides the server's default number of requests per day when positive.
+// Revoked tokens are kept so the record of who held them survives.
+type Token struct {
+	ID         string     `json:"id"`
+	User       string     `json:"user"`
+	Label      string     `json:"label,omitempty"`
+	Hash       string     `json:"hash"`
+	DailyQuota int        `json:"daily_quota,omitempty"`
+	Created    time.Time  `json:"created"`
+	Revoked    *time.Time `json:"revoked,omitempty"`
+}
+
+// Active reports whether the token has not been revoked.
+func (t Token) Active() bool {
+	return t.Revoked == nil
+}
+
+// Store holds accounts and API tokens, persisted as JSON to a file readable only
+// by its owner. Changes are written through immediately. The file is shared with
+// authctl, so the store reads it again whenever it has changed on disk: before
+// every change, so that none made elsewhere is overwritten, and before looking up
+// accounts and tokens, so that revocations take effect at once.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	users   []User
+	tokens  []Token
+	version fileVersion // of the file the accounts were last read from or written to
+}
+
+// fileVersion identifies a version of the accounts file.
+type fileVersion struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// storeFile is the on-disk layout of a Store.
+type storeFile struct {
+	Users  []User  `json:"users"`
+	Tokens []Token `json:"tokens"`
+}
+
+// dummyHash is checked against when a sign-in names an unknown user, so that the
+// response takes as long as for a wrong password.
+var dummyHash = sync.OnceValue(func() string {
+	h, _ := HashPassword("not a real password")
+	return h
+})
+
+// Open loads the store at path. A missing file is treated as an empty store and
+// created on the first change.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Users returns every account, in the order they were added.
+func (s *Store) Users() []User {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.users)
+}
+
+// User returns the named account.
+func (s *Store) User(name string) (User, bool) {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.userIndex(name)
+	if i < 0 {
+		return User{}, false
+	}
+	return s.users[i], true
+}
+
+// AddUser creates an account with the given role and password.
+func (s *Store) AddUser(name string, role Role, password string) error {
+	if !usernamePattern.MatchString(name) {
+		return fmt.Errorf("%w: username must be 1-32 lowercase letters, digits, dots, dashes or underscores", ErrInvalidUser)
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readIfChanged(); err != nil {
+		return err
+	}
+
+	if s.userIndex(name) >= 0 {
+		return fmt.Errorf("%w: %s", ErrUserExists, name)
+	}
+	s.users = append(s.users, User{Name: name, Role: role, Password: hash})
+	return s.save()
+}
+
+// RemoveUser deletes an account along with its API tokens.
+func (s *Store) RemoveUser(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readIfChanged(); err != nil {
+		return err
+	}
+
+	i := s.userIndex(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	s.users = slices.Delete(s.users, i, i+1)
+	s.tokens = slices.DeleteFunc(s.tokens, func(t Token) bool { return t.User == name })
+	return s.save()
+}
+
+// Authenticate returns the account whose name and password match, or an error
+// wrapping ErrInvalidCredentials.
+func (s *Store) Authenticate(name, password string) (User, error) {
+	u, ok := s.User(name)
+	if !ok {
+		CheckPassword(dummyHash(), password)
+		return User{}, ErrInvalidCredentials
+	}
+	if !CheckPassword(u.Password, password) {
+		return User{}, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// IssueToken creates an API token for the named account, returning the secret
+// to hand to the client along with the stored record. A quota of zero uses the
+// server's default.
+func (s *Store) IssueToken(user, label string, quota int) (string, Token, error) {
+	if quota < 0 {
+		return "", Token{}, fmt.Errorf("%w: daily quota cannot be negative", ErrInvalidUser)
+	}
+
+	b := make([]byte, 32)
+	rand.Read(b)
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	id := make([]byte, 4)
+	rand.Read(id)
+	token := Token{
+		ID:         hex.EncodeToString(id),
+		User:       user,
+		Label:      label,
+		Hash:       hashToken(secret),
+		DailyQuota: quota,
+		Created:    time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readIfChanged(); err != nil {
+		return "", Token{}, err
+	}
+
+	if s.userIndex(user) < 0 {
+		return "", Token{}, fmt.Errorf("%w: %s", ErrUserNotFound, user)
+	}
+	s.tokens = append(s.tokens, token)
+	if err := s.save(); err != nil {
+		return "", Token{}, err
+	}
+	return secret, token, nil
+}
+
+// Tokens returns every issued API token, in the order they were issued.
+func (s *Store) Tokens() []Token {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.tokens)
+}
+
+// RevokeToken revokes the token with the given ID so it is no longer accepted.
+func (s *Store) RevokeToken(id string) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readIfChanged(); err != nil {
+		return Token{}, err
+	}
+
+	i := slices.IndexFunc(s.tokens, func(t Token) bool { return t.ID == id })
+	if i < 0 {
+		return Token{}, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	if s.tokens[i].Active() {
+		now := time.Now().UTC()
+		s.tokens[i].Revoked = &now
+		if err := s.save(); err != nil {
+			return Token{}, err
+		}
+	}
+	return s.tokens[i], nil
+}
+
+// LookupToken returns an active API token matching secret and the account it was
+// issued to. Secrets are high-entropy, so a plain hash is enough to compare them
+// safely.
+func (s *Store) LookupToken(secret string) (User, Token, bool) {
+	hash := hashToken(secret)
+
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tokens {
+		if t.Hash == hash && t.Active() {
+			if i := s.userIndex(t.User); i >= 0 {
+				return s.users[i], t, true
+			}
+		}
+	}
+	return User{}, Token{}, false
+}
+
+// userIndex returns the position of the named account, or -1. The caller must
+// hold the lock.
+func (s *Store) userIndex(name string) int {
+	return slices.IndexFunc(s.users, func(u User) bool { return u.Name == name })
+}
+
+// refresh reads the file again if it has changed since it was last read or
+// written. Failures are logged and the accounts already loaded are kept.
+func (s *Store) refresh() {
+	current := s.stat()
+	s.mu.RLock()
+	unchanged := current == s.version
+	s.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readIfChanged(); err != nil {
+		log.Printf("Keeping previously loaded accounts: %v", err)
+	}
+}
+
+// readIfChanged reads the file again if it has changed since it was last read or
+// written. The caller must hold the write lock.
+func (s *Store) readIfChanged() error {
+	if s.stat() == s.version {
+		return nil
+	}
+	return s.read()
+}
+
+// read replaces the accounts and tokens with those in the file. A missing file
+// holds none. The caller must hold the write lock.
+func (s *Store) read() error {
+	version := s.stat()
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.users, s.tokens, s.version = nil, nil, fileVersion{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read accounts: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse accounts in %s: %w", s.path, err)
+	}
+	s.users, s.tokens, s.version = file.Users, file.Tokens, version
+	return nil
+}
+
+// stat returns the version of the file currently on disk.
+func (s *Store) stat() fileVersion {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fileVersion{}
+	}
+	return fileVersion{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+// save writes the store to its file, replacing it atomically. The caller must
+// hold the write lock.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(storeFile{Users: s.users, Tokens: s.tokens}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".accounts-*")
+	if err != nil {
+		return fmt.Errorf("failed to save accounts: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save accounts: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save accounts: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save accounts: %w", err)
+	}
+	s.version = s.stat()
+	return nil
+}
+
+// hashToken returns the hex SHA-256 of a token secret.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
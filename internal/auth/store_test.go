@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestStoreSharesFile opens two stores on one file, as the server and authctl
+// do, and checks that each sees and keeps the other's changes.
+func TestStoreSharesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	server, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := server.AddUser("okafor", RoleAnalyst, "correct horse"); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	secret, token, err := server.IssueToken("okafor", "script", 0)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	cli, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := cli.RevokeToken(token.ID); err != nil {
+		t.Fatalf("RevokeToken from the second store: %v", err)
+	}
+	if err := cli.AddUser("vance", RoleViewer, "battery staple"); err != nil {
+		t.Fatalf("AddUser from the second store: %v", err)
+	}
+
+	// The revocation takes effect without reopening
+	if _, _, ok := server.LookupToken(secret); ok {
+		t.Error("token revoked by the second store is still accepted")
+	}
+
+	// A change made by the server keeps the second store's changes
+	if _, _, err := server.IssueToken("vance", "dashboard", 0); err != nil {
+		t.Fatalf("IssueToken for a user added by the second store: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	names := make([]string, 0, 2)
+	for _, u := range reopened.Users() {
+		names = append(names, u.Name)
+	}
+	if !slices.Equal(names, []string{"okafor", "vance"}) {
+		t.Errorf("users on disk = %v, want [okafor vance]", names)
+	}
+	tokens := reopened.Tokens()
+	if len(tokens) != 2 || tokens[0].Active() || !tokens[1].Active() {
+		t.Errorf("tokens on disk = %+v, want the revoked one and a new active one", tokens)
+	}
+}
@@ -0,0 +1,454 @@
+// Package cryptid implements a creature generator for cryptid sightings.
+// Each creature belongs to the regions and habitats of its folklore, and sightings read
+// like eyewitness accounts backed by witnesses and physical evidence.
+package cryptid
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+	"github.com/pymk/creature-sighting/internal/creatures/witness"
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/narrative"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/weather"
+)
+
+// Witness count bounds for generated reports.
+const (
+	minWitnesses = 1
+	maxWitnesses = 12
+)
+
+// siteRangeKm is how far a requested location may be from the folklore site of the
+// creature seen there.
+const siteRangeKm = 200.0
+
+// Evidence types a witness may bring back.
+var evidenceTypes = []string{"photo", "footprint", "audio"}
+
+// Credibility ratings assigned from witness count and evidence strength.
+var credibilityRatings = []string{"low", "moderate", "high"}
+
+// habitats lists every habitat a cryptid site can belong to.
+var habitats = []string{"forest", "lake", "mountain", "river valley", "scrubland", "swamp", "rainforest"}
+
+// site is a place where a cryptid is traditionally reported.
+type site struct {
+	location sighting.Location
+	habitat  string
+	landmark string
+}
+
+// creature is a cryptid from folklore along with the places it haunts and the
+// details witnesses tend to describe.
+type creature struct {
+	name   string
+	kind   string
+	sites  []site
+	traits []string
+}
+
+// Generator creates random cryptid sightings tied to each creature's home regions.
+type Generator struct {
+	grammar   *narrative.Grammar
+	creatures []creature
+}
+
+// NewGenerator creates a new cryptid generator with predefined folklore data.
+func NewGenerator() *Generator {
+	return &Generator{
+		grammar: narrative.MustLoad("cryptid"),
+		creatures: []creature{
+			{
+				name: "Bigfoot",
+				kind: "Hominid",
+				sites: []site{
+					{sighting.Location{Latitude: 40.9396, Longitude: -123.6317, City: "Willow Creek", Country: "USA", Region: "North America"}, "forest", "the old logging road above Bluff Creek"},
+					{sighting.Location{Latitude: 46.1914, Longitude: -122.1956, City: "Mount St. Helens", Country: "USA", Region: "North America"}, "forest", "the Ape Canyon trailhead"},
+				},
+				traits: []string{
+					"easily eight feet tall and covered in dark reddish hair",
+					"walking upright with long arms swinging past its knees",
+					"turning its whole upper body to look back at us",
+				},
+			},
+			{
+				name: "Loch Ness Monster",
+				kind: "Lake Monster",
+				sites: []site{
+					{sighting.Location{Latitude: 57.3229, Longitude: -4.4244, City: "Drumnadrochit", Country: "UK", Region: "Europe"}, "lake", "the shore below Urquhart Castle"},
+				},
+				traits: []string{
+					"a long neck rising straight out of the water",
+					"two dark humps moving steadily against the current",
+					"a wake far too big for any boat I could see",
+				},
+			},
+			{
+				name: "Mothman",
+				kind: "Winged Humanoid",
+				sites: []site{
+					{sighting.Location{Latitude: 38.8445, Longitude: -82.1371, City: "Point Pleasant", Country: "USA", Region: "North America"}, "river valley", "the abandoned TNT plant"},
+				},
+				traits: []string{
+					"a man-shaped figure with wings folded like a cloak",
+					"two glowing red eyes that stayed fixed on the car",
+					"lifting straight up without flapping once",
+				},
+			},
+			{
+				name: "Yeti",
+				kind: "Hominid",
+				sites: []site{
+					{sighting.Location{Latitude: 27.8167, Longitude: 86.7167, City: "Khumjung", Country: "Nepal", Region: "Asia"}, "mountain", "the moraine above the monastery"},
+					{sighting.Location{Latitude: 27.6870, Longitude: 86.7314, City: "Lukla", Country: "Nepal", Region: "Asia"}, "mountain", "the ridge trail toward Namche"},
+				},
+				traits: []string{
+					"pale and shaggy, almost invisible against the snow",
+					"moving across the scree faster than any climber",
+					"leaving a smell like wet animal on the wind",
+				},
+			},
+			{
+				name: "Chupacabra",
+				kind: "Predator",
+				sites: []site{
+					{sighting.Location{Latitude: 18.3792, Longitude: -65.9011, City: "Canóvanas", Country: "Puerto Rico", Region: "North America"}, "scrubland", "the goat pens past the river"},
+					{sighting.Location{Latitude: 29.0939, Longitude: -97.2892, City: "Cuero", Country: "USA", Region: "North America"}, "scrubland", "the ranch road outside town"},
+				},
+				traits: []string{
+					"hairless and grey with a ridge of spines down its back",
+					"hopping on its hind legs like a kangaroo",
+					"crouched over one of the goats with its head down",
+				},
+			},
+			{
+				name: "Yowie",
+				kind: "Hominid",
+				sites: []site{
+					{sighting.Location{Latitude: -33.7125, Longitude: 150.3119, City: "Katoomba", Country: "Australia", Region: "Oceania"}, "forest", "the fire trail below the Three Sisters"},
+				},
+				traits: []string{
+					"broad-shouldered and hunched, with hair the colour of bark",
+					"pulling branches aside as if they weighed nothing",
+					"gone between the gums before I could shout",
+				},
+			},
+			{
+				name: "Mokele-mbembe",
+				kind: "Lake Monster",
+				sites: []site{
+					{sighting.Location{Latitude: 1.3467, Longitude: 17.1544, City: "Boha", Country: "Republic of the Congo", Region: "Africa"}, "swamp", "the channel leading to Lake Tele"},
+				},
+				traits: []string{
+					"a small head on a neck as long as a canoe",
+					"a back like an elephant's just breaking the surface",
+					"pushing a wave through the reeds as it moved off",
+				},
+			},
+			{
+				name: "Ogopogo",
+				kind: "Lake Monster",
+				sites: []site{
+					{sighting.Location{Latitude: 49.8880, Longitude: -119.4960, City: "Kelowna", Country: "Canada", Region: "North America"}, "lake", "the beach near Rattlesnake Island"},
+				},
+				traits: []string{
+					"a series of green-black coils rolling through the water",
+					"a head shaped something like a horse's",
+					"at least fifteen metres long from first hump to last",
+				},
+			},
+			{
+				name: "Jersey Devil",
+				kind: "Winged Humanoid",
+				sites: []site{
+					{sighting.Location{Latitude: 39.8173, Longitude: -74.5346, City: "Chatsworth", Country: "USA", Region: "North America"}, "forest", "the sand road through the Pine Barrens"},
+				},
+				traits: []string{
+					"hooves, a long tail and leathery wings",
+					"a scream that sounded almost like a woman",
+					"perched on a fence post before it took off over the pines",
+				},
+			},
+			{
+				name: "Almas",
+				kind: "Hominid",
+				sites: []site{
+					{sighting.Location{Latitude: 48.0056, Longitude: 91.6419, City: "Khovd", Country: "Mongolia", Region: "Asia"}, "mountain", "the herders' pass in the Altai foothills"},
+				},
+				traits: []string{
+					"shorter than a man, with a heavy brow and reddish hair",
+					"squatting by the stream and drinking from its hands",
+					"watching the horses from the rocks for a long time",
+				},
+			},
+			{
+				name: "Mapinguari",
+				kind: "Predator",
+				sites: []site{
+					{sighting.Location{Latitude: -3.4653, Longitude: -62.2159, City: "Tefé", Country: "Brazil", Region: "South America"}, "rainforest", "the trail between the rubber trees"},
+				},
+				traits: []string{
+					"a huge shaggy shape standing on two legs",
+					"a stench so bad the dogs refused to follow",
+					"tearing through the undergrowth with long curved claws",
+				},
+			},
+		},
+	}
+}
+
+// Category returns the creature category this generator handles.
+func (g *Generator) Category() string {
+	return "cryptid"
+}
+
+// Describe returns the metadata for the cryptid category.
+func (g *Generator) Describe() sighting.CategoryInfo {
+	return sighting.CategoryInfo{
+		DisplayName: "Cryptid",
+		Description: "Elusive regional entities, low threat level",
+		Criteria: "Entities documented in regional folklore but absent from the scientific record, " +
+			"reported by civilian witnesses within their traditional range. Classification depends on corroborating evidence.",
+		Types: g.types(),
+		Attributes: sighting.Schema{
+			{
+				Name:        "habitat",
+				Label:       "Habitat",
+				Description: "Terrain where the encounter took place",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(habitats),
+				Required:    true,
+			},
+			{
+				Name:        "witness_count",
+				Label:       "Witnesses",
+				Description: "Number of people present during the encounter",
+				Type:        sighting.AttributeInt,
+				Min:         sighting.Bound(minWitnesses),
+				Max:         sighting.Bound(maxWitnesses),
+				Required:    true,
+			},
+			{
+				Name:        "evidence_type",
+				Label:       "Evidence",
+				Description: "Physical evidence recovered by the witnesses",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(evidenceTypes),
+				Required:    true,
+			},
+			{
+				Name:        "credibility",
+				Label:       "Credibility",
+				Description: "Assessed reliability of the account",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(credibilityRatings),
+				Required:    true,
+			},
+		},
+	}
+}
+
+// AssessThreat rates cryptids as low, or guarded for predators, one level higher
+// when the account is highly credible.
+func (g *Generator) AssessThreat(s *sighting.Sighting) sighting.Threat {
+	threat := sighting.ThreatLow
+	if s.Type == "Predator" {
+		threat = sighting.ThreatGuarded
+	}
+	if s.Attributes.Text("credibility") == "high" {
+		threat = threat.Raise(1)
+	}
+	return threat
+}
+
+// Generate creates a random cryptid sighting within the creature's home range.
+func (g *Generator) Generate() (*sighting.Sighting, error) {
+	return g.GenerateWithOptions(context.Background(), sighting.Options{})
+}
+
+// GenerateWithOptions creates a cryptid sighting honouring the requested region, location,
+// timestamp, type and seed. Region and type narrow the creatures that may be chosen.
+func (g *Generator) GenerateWithOptions(ctx context.Context, opts sighting.Options) (*sighting.Sighting, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rng := random.New(opts.Seed)
+
+	c, s, err := g.chooseCreature(rng, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	witnesses, err := rng.Int(minWitnesses, maxWitnesses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate witness count: %w", err)
+	}
+
+	evidence, err := rng.Choice(evidenceTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate evidence type: %w", err)
+	}
+
+	credibility, err := g.assessCredibility(rng, witnesses, evidence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assess credibility: %w", err)
+	}
+
+	loc := s.location
+	if opts.Location != nil {
+		loc = *opts.Location
+	}
+
+	timestamp := opts.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	report := &sighting.Sighting{
+		ID:        fmt.Sprintf("cryptid-%d", time.Now().UnixNano()),
+		Name:      c.name,
+		Type:      c.kind,
+		Category:  g.Category(),
+		Location:  loc,
+		Timestamp: timestamp,
+		Attributes: sighting.Attributes{
+			"habitat":       s.habitat,
+			"witness_count": witnesses,
+			"evidence_type": evidence,
+			"credibility":   credibility,
+		},
+	}
+
+	conditions := weather.At(loc.Latitude, loc.Longitude, timestamp)
+	report.Weather = &conditions
+
+	// The account is told in the witness's words, using the creature's traits and the site's landmark
+	vars := narrative.SightingVars(report, g.Describe().Attributes)
+	vars.Set("trait", c.traits...)
+	vars.Set("landmark", s.landmark)
+	vars.Set("company", "solo")
+	if witnesses > 1 {
+		vars.Set("company", "group")
+	}
+	report.Description, err = g.grammar.Generate(rng, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	// Not every witness comes forward
+	count, err := rng.Int(1, min(witnesses, 3))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report count: %w", err)
+	}
+	report.Reports, err = witness.Reports(rng, report, g.Describe(), witness.Options{
+		Count:       count,
+		MinDistance: 5,
+		MaxDistance: 300,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// types returns the distinct creature types in catalog order.
+func (g *Generator) types() []string {
+	types := make([]string, 0, len(g.creatures))
+	for _, c := range g.creatures {
+		if !slices.Contains(types, c.kind) {
+			types = append(types, c.kind)
+		}
+	}
+	return types
+}
+
+// chooseCreature picks a creature and one of its sites, restricted to the requested
+// type and region. Folklore ties each creature to its sites, so a region with no
+// matching creature is an error rather than a relocation. A requested location
+// takes the nearest matching site instead, and is an error when that site is more
+// than siteRangeKm away.
+func (g *Generator) chooseCreature(rng *random.Source, opts sighting.Options) (creature, site, error) {
+	type candidate struct {
+		creature creature
+		site     site
+	}
+
+	var candidates []candidate
+	for _, c := range g.creatures {
+		if opts.Type != "" && !strings.EqualFold(c.kind, opts.Type) {
+			continue
+		}
+		for _, s := range c.sites {
+			if opts.Region != "" && opts.Location == nil && !strings.EqualFold(s.location.Region, opts.Region) {
+				continue
+			}
+			candidates = append(candidates, candidate{c, s})
+		}
+	}
+
+	if loc := opts.Location; loc != nil {
+		nearest, best := -1, siteRangeKm
+		for i, c := range candidates {
+			if d := geo.Distance(loc.Latitude, loc.Longitude, c.site.location.Latitude, c.site.location.Longitude); d <= best {
+				nearest, best = i, d
+			}
+		}
+		if nearest < 0 {
+			return creature{}, site{}, fmt.Errorf("%w: no cryptid of type %q is reported within %g km of %g, %g",
+				sighting.ErrInvalidOptions, opts.Type, siteRangeKm, loc.Latitude, loc.Longitude)
+		}
+		return candidates[nearest].creature, candidates[nearest].site, nil
+	}
+
+	if len(candidates) == 0 {
+		return creature{}, site{}, fmt.Errorf("%w: no cryptid of type %q is reported in region %q",
+			sighting.ErrInvalidOptions, opts.Type, opts.Region)
+	}
+
+	idx, err := rng.Int(0, len(candidates)-1)
+	if err != nil {
+		return creature{}, site{}, fmt.Errorf("failed to choose creature: %w", err)
+	}
+	return candidates[idx].creature, candidates[idx].site, nil
+}
+
+// assessCredibility rates an account from its witness count and evidence, with some
+// analyst discretion mixed in.
+func (g *Generator) assessCredibility(rng *random.Source, witnesses int, evidence string) (string, error) {
+	score := 0
+	switch evidence {
+	case "photo":
+		score += 2
+	case "footprint", "audio":
+		score++
+	}
+	switch {
+	case witnesses >= 5:
+		score += 2
+	case witnesses >= 2:
+		score++
+	}
+
+	discretion, err := rng.Int(0, 1)
+	if err != nil {
+		return "", err
+	}
+	score += discretion
+
+	switch {
+	case score >= 4:
+		return "high", nil
+	case score >= 2:
+		return "moderate", nil
+	default:
+		return "low", nil
+	}
+}
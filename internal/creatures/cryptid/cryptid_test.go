@@ -0,0 +1,43 @@
+package cryptid
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+func TestRequestedLocationNearFolklore(t *testing.T) {
+	g := NewGenerator()
+
+	tests := []struct {
+		name     string
+		kind     string
+		location sighting.Location
+		want     string // creature seen, or empty for an options error
+	}{
+		{"Loch Ness", "", sighting.Location{Latitude: 57.3, Longitude: -4.45}, "Loch Ness Monster"},
+		{"Sahara", "", sighting.Location{Latitude: 23.4, Longitude: 12.1}, ""},
+		{"Loch Ness for another type", "Hominid", sighting.Location{Latitude: 57.3, Longitude: -4.45}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed := int64(1)
+			loc := tt.location
+			s, err := g.GenerateWithOptions(context.Background(), sighting.Options{Seed: &seed, Type: tt.kind, Location: &loc})
+			if tt.want == "" {
+				if !errors.Is(err, sighting.ErrInvalidOptions) {
+					t.Errorf("GenerateWithOptions = %v, want ErrInvalidOptions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateWithOptions: %v", err)
+			}
+			if s.Name != tt.want || s.Location != loc {
+				t.Errorf("got %s at %+v, want %s at %+v", s.Name, s.Location, tt.want, loc)
+			}
+		})
+	}
+}
@@ -3,17 +3,32 @@
 package kaiju
 
 import (
-	"crypto/rand"
+	"context"
 	"fmt"
-	"math/big"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/pymk/creature-sighting/internal/creatures/model"
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+	"github.com/pymk/creature-sighting/internal/creatures/witness"
+	"github.com/pymk/creature-sighting/internal/names"
+	"github.com/pymk/creature-sighting/internal/narrative"
 	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/weather"
 )
 
-// Generator creates random kaiju sightings with predefined sets of names, types, and attributes.
+// Height bounds in meters for generated kaiju.
+const (
+	minHeight = 50
+	maxHeight = 300
+)
+
+// Generator creates random kaiju sightings with generated names and predefined sets of types and attributes.
 type Generator struct {
-	names     []string
+	namer     *names.Namer
+	grammar   *narrative.Grammar
+	model     *model.Model
 	types     []string
 	behaviors []string
 	sizes     []string
@@ -22,11 +37,8 @@ type Generator struct {
 // NewGenerator creates a new kaiju generator with predefined creature data.
 func NewGenerator() *Generator {
 	return &Generator{
-		names: []string{
-			"Gorgozilla", "Mechataur", "Tsunamius", "Pyroclast",
-			"Vortexia", "Thundermaw", "Crystalfang", "Nebulox",
-			"Seismodon", "Glacierus", "Plasmoid", "Terracrush",
-		},
+		namer:   names.MustNewNamer("kaiju"),
+		grammar: narrative.MustLoad("kaiju"),
 		types: []string{
 			"Aquatic", "Terrestrial", "Aerial", "Subterranean",
 			"Amphibious", "Cosmic", "Volcanic", "Arctic",
@@ -36,109 +48,306 @@ func NewGenerator() *Generator {
 			"migratory", "nocturnal", "predatory", "docile",
 		},
 		sizes: []string{
-			"colossal", "massive", "enormous", "gigantic",
-			"titanic", "monstrous", "immense", "gargantuan",
+			"massive", "enormous", "immense", "monstrous",
+			"gigantic", "colossal", "titanic", "gargantuan",
 		},
+		model: attributeModel,
 	}
 }
 
+// attributeModel declares how kaiju attributes depend on one another. Behavior follows
+// from type, height is bounded by the size word, and the requirements keep Aquatic
+// kaiju on coasts and Arctic kaiju at high latitudes.
+var attributeModel = &model.Model{
+	Rules: []model.Rule{
+		model.Enum{
+			Name:  "behavior",
+			Given: "type",
+			Table: map[string]model.Weights{
+				"Aquatic":      {"territorial": 3, "predatory": 3, "migratory": 2, "aggressive": 2, "curious": 1, "defensive": 1, "nocturnal": 1},
+				"Terrestrial":  {"aggressive": 3, "territorial": 3, "defensive": 2, "predatory": 2, "curious": 1, "docile": 1},
+				"Aerial":       {"migratory": 3, "predatory": 3, "curious": 2, "aggressive": 1, "territorial": 1},
+				"Subterranean": {"nocturnal": 3, "defensive": 3, "territorial": 2, "aggressive": 1, "curious": 1},
+				"Amphibious":   {"curious": 2, "territorial": 2, "predatory": 2, "defensive": 2, "docile": 1, "migratory": 1},
+				"Cosmic":       {"curious": 3, "aggressive": 2, "docile": 2, "migratory": 1, "defensive": 1},
+				"Volcanic":     {"aggressive": 4, "territorial": 3, "defensive": 1},
+				"Arctic":       {"migratory": 3, "docile": 2, "defensive": 2, "nocturnal": 2, "territorial": 1},
+			},
+		},
+		model.Enum{
+			Name: "size",
+			Default: model.Weights{
+				"massive": 1, "enormous": 1, "immense": 1, "monstrous": 1,
+				"gigantic": 1, "colossal": 1, "titanic": 1, "gargantuan": 1,
+			},
+		},
+		model.Int{
+			Name:  "height",
+			Given: "size",
+			Table: map[string]model.Bounds{
+				"massive":    {Min: minHeight, Max: 110},
+				"enormous":   {Min: 80, Max: 140},
+				"immense":    {Min: 100, Max: 170},
+				"monstrous":  {Min: 120, Max: 200},
+				"gigantic":   {Min: 150, Max: 230},
+				"colossal":   {Min: 180, Max: 260},
+				"titanic":    {Min: 210, Max: 280},
+				"gargantuan": {Min: 240, Max: maxHeight},
+			},
+			Default: model.Bounds{Min: minHeight, Max: maxHeight},
+		},
+	},
+	Requirements: []model.Requirement{
+		{If: map[string]string{"type": "Aquatic"}, Then: map[string][]string{"coast": {"coastal"}}},
+		{If: map[string]string{"type": "Arctic"}, Then: map[string][]string{"latitude": {"high"}}},
+	},
+}
+
+// SetNameCheck installs a check reporting creature names already in use, so that
+// generated names stay unique.
+func (g *Generator) SetNameCheck(taken func(name string) bool) {
+	g.namer.SetTaken(taken)
+}
+
 // Category returns the creature category this generator handles.
 func (g *Generator) Category() string {
 	return "kaiju"
 }
 
+// Describe returns the metadata for the kaiju category.
+func (g *Generator) Describe() sighting.CategoryInfo {
+	return sighting.CategoryInfo{
+		DisplayName: "Kaiju",
+		Description: "Large-scale entities, urban threat level",
+		Criteria: "Entities exceeding 50m height, displaying aggressive territorial behavior, " +
+			"capable of significant infrastructure damage. Requires immediate containment protocols upon detection.",
+		Types: slices.Clone(g.types),
+		Attributes: sighting.Schema{
+			{
+				Name:        "size",
+				Label:       "Size",
+				Description: "Observed scale relative to surroundings",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(g.sizes),
+				Required:    true,
+			},
+			{
+				Name:        "behavior",
+				Label:       "Behavior",
+				Description: "Dominant behavioral pattern during the encounter",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(g.behaviors),
+				Required:    true,
+			},
+			{
+				Name:        "height",
+				Label:       "Height",
+				Description: "Estimated standing height",
+				Type:        sighting.AttributeInt,
+				Unit:        "meters",
+				Min:         sighting.Bound(minHeight),
+				Max:         sighting.Bound(maxHeight),
+				Required:    true,
+			},
+		},
+	}
+}
+
+// AssessThreat rates a kaiju by its height, from guarded for the smallest to severe
+// for the tallest, one level higher if it is aggressive or predatory and one lower
+// if it is docile or merely curious.
+func (g *Generator) AssessThreat(s *sighting.Sighting) sighting.Threat {
+	threat := sighting.ThreatGuarded
+	if height, ok := s.Attributes.Number("height"); ok {
+		switch {
+		case height >= 240:
+			threat = sighting.ThreatSevere
+		case height >= 180:
+			threat = sighting.ThreatHigh
+		case height >= 100:
+			threat = sighting.ThreatElevated
+		}
+	}
+
+	switch s.Attributes.Text("behavior") {
+	case "aggressive", "predatory":
+		threat = threat.Raise(1)
+	case "docile", "curious":
+		threat = threat.Raise(-1)
+	}
+	return threat
+}
+
 // Generate creates a random kaiju sighting with randomized attributes and location.
 func (g *Generator) Generate() (*sighting.Sighting, error) {
-	loc := g.randomLocation()
+	return g.GenerateWithOptions(context.Background(), sighting.Options{})
+}
 
-	name, err := g.randomChoice(g.names)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate name: %w", err)
+// GenerateWithOptions creates a kaiju sighting honouring the requested region, location,
+// timestamp, type and seed. A seed makes every random choice reproducible.
+func (g *Generator) GenerateWithOptions(ctx context.Context, opts sighting.Options) (*sighting.Sighting, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	kaijuType, err := g.randomChoice(g.types)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate type: %w", err)
+	rng := random.New(opts.Seed)
+
+	if opts.Type != "" && !slices.Contains(g.types, opts.Type) {
+		return nil, fmt.Errorf("%w: unknown kaiju type %q", sighting.ErrInvalidOptions, opts.Type)
 	}
 
-	behavior, err := g.randomChoice(g.behaviors)
+	candidates, err := g.candidateLocations(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate behavior: %w", err)
+		return nil, err
+	}
+
+	kaijuType := opts.Type
+	if kaijuType == "" {
+		kaijuType, err = g.chooseType(rng, candidates)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	size, err := g.randomChoice(g.sizes)
+	loc, err := g.chooseLocation(rng, kaijuType, candidates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate size: %w", err)
+		return nil, err
 	}
 
-	height, err := g.randomInt(50, 300)
+	name, err := g.namer.Name(rng)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate height: %w", err)
+		return nil, fmt.Errorf("failed to generate name: %w", err)
+	}
+
+	values := model.Merge(model.Values{"type": kaijuType}, model.Location(loc.Latitude, loc.Longitude))
+	if err := g.model.Draw(rng, values); err != nil {
+		return nil, err
 	}
 
-	sighting := &sighting.Sighting{
-		ID:          fmt.Sprintf("kaiju-%d", time.Now().UnixNano()),
-		Name:        name,
-		Type:        kaijuType,
-		Category:    g.Category(),
-		Location:    loc,
-		Description: fmt.Sprintf("A %s %s kaiju displaying %s behavior", size, kaijuType, behavior),
-		Timestamp:   time.Now(),
+	timestamp := opts.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	s := &sighting.Sighting{
+		ID:        fmt.Sprintf("kaiju-%d", time.Now().UnixNano()),
+		Name:      name,
+		Type:      kaijuType,
+		Category:  g.Category(),
+		Location:  loc,
+		Timestamp: timestamp,
 		Attributes: sighting.Attributes{
-			"size":     size,
-			"behavior": behavior,
-			"height":   fmt.Sprintf("%d meters", height),
+			"size":     values["size"],
+			"behavior": values["behavior"],
+			"height":   values["height"],
 		},
 	}
 
-	return sighting, nil
+	conditions := weather.At(loc.Latitude, loc.Longitude, timestamp)
+	s.Weather = &conditions
+
+	s.Description, err = g.grammar.Generate(rng, narrative.SightingVars(s, g.Describe().Attributes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	count, err := rng.Int(2, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report count: %w", err)
+	}
+	s.Reports, err = witness.Reports(rng, s, g.Describe(), witness.Options{
+		Count:       count,
+		MinDistance: 200,
+		MaxDistance: 5000,
+		Details:     []string{"height", "size", "behavior"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
-// randomLocation selects a random location from predefined major cities worldwide.
-func (g *Generator) randomLocation() sighting.Location {
-	locations := []sighting.Location{
-		{Latitude: 35.6762, Longitude: 139.6503, City: "Tokyo", Country: "Japan", Region: "Asia"},
-		{Latitude: 37.7749, Longitude: -122.4194, City: "San Francisco", Country: "USA", Region: "North America"},
-		{Latitude: -33.8688, Longitude: 151.2093, City: "Sydney", Country: "Australia", Region: "Oceania"},
-		{Latitude: 51.5074, Longitude: -0.1278, City: "London", Country: "UK", Region: "Europe"},
-		{Latitude: -22.9068, Longitude: -43.1729, City: "Rio de Janeiro", Country: "Brazil", Region: "South America"},
-		{Latitude: 40.7128, Longitude: -74.0060, City: "New York", Country: "USA", Region: "North America"},
-		{Latitude: 1.3521, Longitude: 103.8198, City: "Singapore", Country: "Singapore", Region: "Asia"},
-		{Latitude: 64.1466, Longitude: -21.9426, City: "Reykjavik", Country: "Iceland", Region: "Europe"},
-		{Latitude: -1.2921, Longitude: 36.8219, City: "Nairobi", Country: "Kenya", Region: "Africa"},
-		{Latitude: 19.4326, Longitude: -99.1332, City: "Mexico City", Country: "Mexico", Region: "North America"},
-	}
-
-	idx, _ := g.randomInt(0, len(locations)-1)
-	return locations[idx]
+// locations lists the predefined major cities worldwide where kaiju may appear.
+var locations = []sighting.Location{
+	{Latitude: 35.6762, Longitude: 139.6503, City: "Tokyo", Country: "Japan", Region: "Asia"},
+	{Latitude: 37.7749, Longitude: -122.4194, City: "San Francisco", Country: "USA", Region: "North America"},
+	{Latitude: -33.8688, Longitude: 151.2093, City: "Sydney", Country: "Australia", Region: "Oceania"},
+	{Latitude: 51.5074, Longitude: -0.1278, City: "London", Country: "UK", Region: "Europe"},
+	{Latitude: -22.9068, Longitude: -43.1729, City: "Rio de Janeiro", Country: "Brazil", Region: "South America"},
+	{Latitude: 40.7128, Longitude: -74.0060, City: "New York", Country: "USA", Region: "North America"},
+	{Latitude: 1.3521, Longitude: 103.8198, City: "Singapore", Country: "Singapore", Region: "Asia"},
+	{Latitude: 64.1466, Longitude: -21.9426, City: "Reykjavik", Country: "Iceland", Region: "Europe"},
+	{Latitude: -1.2921, Longitude: 36.8219, City: "Nairobi", Country: "Kenya", Region: "Africa"},
+	{Latitude: 19.4326, Longitude: -99.1332, City: "Mexico City", Country: "Mexico", Region: "North America"},
 }
 
-// randomChoice selects a random string from the provided choices slice.
-func (g *Generator) randomChoice(choices []string) (string, error) {
-	if len(choices) == 0 {
-		return "", fmt.Errorf("empty choices")
+// candidateLocations returns the caller-supplied location, or the cities in the
+// requested region when one is given.
+func (g *Generator) candidateLocations(opts sighting.Options) ([]sighting.Location, error) {
+	if opts.Location != nil {
+		return []sighting.Location{*opts.Location}, nil
 	}
 
-	idx, err := g.randomInt(0, len(choices)-1)
-	if err != nil {
-		return "", err
+	candidates := make([]sighting.Location, 0, len(locations))
+	for _, loc := range locations {
+		if opts.Region == "" || strings.EqualFold(loc.Region, opts.Region) {
+			candidates = append(candidates, loc)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no kaiju locations in region %q", sighting.ErrInvalidOptions, opts.Region)
+	}
+	return candidates, nil
+}
+
+// chooseType picks a random type that can appear in at least one of the candidate locations.
+func (g *Generator) chooseType(rng *random.Source, candidates []sighting.Location) (string, error) {
+	var types []string
+	for _, t := range g.types {
+		if len(g.permitted(t, candidates)) > 0 {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return "", fmt.Errorf("%w: no kaiju type can appear at the requested location", sighting.ErrInvalidOptions)
 	}
 
-	return choices[idx], nil
+	kaijuType, err := rng.Choice(types)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate type: %w", err)
+	}
+	return kaijuType, nil
 }
 
-// randomInt generates a cryptographically secure random integer in the range [min, max].
-// Uses crypto/rand for security rather than math/rand for unpredictability.
-func (g *Generator) randomInt(min, max int) (int, error) {
-	if min > max {
-		return 0, fmt.Errorf("min cannot be greater than max")
+// chooseLocation picks a random candidate location where the type can appear.
+func (g *Generator) chooseLocation(rng *random.Source, kaijuType string, candidates []sighting.Location) (sighting.Location, error) {
+	permitted := g.permitted(kaijuType, candidates)
+	if len(permitted) == 0 {
+		if len(candidates) == 1 {
+			// A requested location: explain which requirement it breaks
+			loc := candidates[0]
+			err := g.model.Check(model.Merge(model.Values{"type": kaijuType}, model.Location(loc.Latitude, loc.Longitude)))
+			return sighting.Location{}, fmt.Errorf("%w: %v", sighting.ErrInvalidOptions, err)
+		}
+		return sighting.Location{}, fmt.Errorf("%w: no %s kaiju locations in the requested region", sighting.ErrInvalidOptions, kaijuType)
 	}
 
-	// Use crypto/rand for security - prevents predictable sequences
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+	idx, err := rng.Int(0, len(permitted)-1)
 	if err != nil {
-		return 0, err
+		return sighting.Location{}, fmt.Errorf("failed to generate location: %w", err)
 	}
+	return permitted[idx], nil
+}
 
-	return int(n.Int64()) + min, nil
+// permitted returns the candidate locations where the attribute model allows the type.
+func (g *Generator) permitted(kaijuType string, candidates []sighting.Location) []sighting.Location {
+	var permitted []sighting.Location
+	for _, loc := range candidates {
+		values := model.Merge(model.Values{"type": kaijuType}, model.Location(loc.Latitude, loc.Longitude))
+		if g.model.Permits(values) {
+			permitted = append(permitted, loc)
+		}
+	}
+	return permitted
 }
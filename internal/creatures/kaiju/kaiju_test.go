@@ -0,0 +1,31 @@
+package kaiju
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+func TestArcticKaijuAtHighLatitudes(t *testing.T) {
+	g := NewGenerator()
+	for seed := int64(1); seed <= 200; seed++ {
+		s, err := g.GenerateWithOptions(context.Background(), sighting.Options{Type: "Arctic", Seed: &seed})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if lat := s.Location.Latitude; math.Abs(lat) < geo.HighLatitude {
+			t.Fatalf("seed %d: Arctic kaiju in %s at latitude %g", seed, s.Location.City, lat)
+		}
+	}
+
+	// London is subpolar but not high latitude ground
+	london := &sighting.Location{Latitude: 51.5074, Longitude: -0.1278}
+	_, err := g.GenerateWithOptions(context.Background(), sighting.Options{Type: "Arctic", Location: london})
+	if !errors.Is(err, sighting.ErrInvalidOptions) {
+		t.Errorf("Arctic kaiju in London: err = %v, want ErrInvalidOptions", err)
+	}
+}
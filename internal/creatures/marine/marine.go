@@ -0,0 +1,379 @@
+// Package marine implements a creature generator for sea creature sightings.
+// Sightings are placed only at sea or on coastlines using the land mask in package geo,
+// and record water depth, sea state and the nearest port.
+package marine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/creatures/model"
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+	"github.com/pymk/creature-sighting/internal/creatures/witness"
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/names"
+	"github.com/pymk/creature-sighting/internal/narrative"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/weather"
+)
+
+// Placement and depth bounds for generated sightings.
+const (
+	minOffshore     = 10   // km from the origin port
+	maxOffshore     = 400  // km from the origin port
+	maxPlacements   = 50   // attempts to land a point at sea before giving up
+	minDepth        = 5    // meters
+	maxCoastalDepth = 400  // meters
+	maxDepth        = 6000 // meters
+)
+
+// seaStates is the Douglas sea scale from calm to phenomenal.
+var seaStates = []string{
+	"calm", "smooth", "slight", "moderate", "rough", "very rough", "high", "very high", "phenomenal",
+}
+
+// port is a harbour sightings are reported from, with the body of water it serves.
+type port struct {
+	name    string
+	country string
+	region  string
+	sea     string
+	lat     float64
+	lon     float64
+}
+
+// Generator creates random sea creature sightings restricted to ocean coordinates.
+type Generator struct {
+	namer     *names.Namer
+	grammar   *narrative.Grammar
+	model     *model.Model
+	types     []string
+	behaviors []string
+	ports     []port
+}
+
+// NewGenerator creates a new marine generator with predefined creature and port data.
+func NewGenerator() *Generator {
+	return &Generator{
+		namer:   names.MustNewNamer("marine"),
+		grammar: narrative.MustLoad("marine"),
+		model:   attributeModel,
+		types: []string{
+			"Cephalopod", "Sea Serpent", "Leviathan", "Crustacean", "Abyssal",
+		},
+		behaviors: []string{
+			"surfacing", "circling", "shadowing a vessel", "breaching", "feeding", "diving",
+		},
+		ports: []port{
+			{"Reykjavik", "Iceland", "Europe", "North Atlantic", 64.1466, -21.9426},
+			{"Bergen", "Norway", "Europe", "Norwegian Sea", 60.3913, 5.3221},
+			{"Aberdeen", "UK", "Europe", "North Sea", 57.1497, -2.0943},
+			{"Lisbon", "Portugal", "Europe", "North Atlantic", 38.7223, -9.1393},
+			{"Halifax", "Canada", "North America", "North Atlantic", 44.6488, -63.5752},
+			{"San Diego", "USA", "North America", "North Pacific", 32.7157, -117.1611},
+			{"Honolulu", "USA", "Oceania", "North Pacific", 21.3069, -157.8583},
+			{"Valparaíso", "Chile", "South America", "South Pacific", -33.0472, -71.6127},
+			{"Ushuaia", "Argentina", "South America", "Southern Ocean", -54.8019, -68.3030},
+			{"Dakar", "Senegal", "Africa", "Eastern Atlantic", 14.7167, -17.4677},
+			{"Cape Town", "South Africa", "Africa", "South Atlantic", -33.9249, 18.4241},
+			{"Mombasa", "Kenya", "Africa", "Indian Ocean", -4.0435, 39.6682},
+			{"Mumbai", "India", "Asia", "Arabian Sea", 19.0760, 72.8777},
+			{"Yokohama", "Japan", "Asia", "Philippine Sea", 35.4437, 139.6380},
+			{"Manila", "Philippines", "Asia", "South China Sea", 14.5995, 120.9842},
+			{"Hobart", "Australia", "Oceania", "Tasman Sea", -42.8821, 147.3272},
+			{"Auckland", "New Zealand", "Oceania", "South Pacific", -36.8485, 174.7633},
+		},
+	}
+}
+
+// attributeModel declares how marine attributes depend on one another. Behavior follows
+// from type, and depth from whether the sighting is over the continental shelf, given
+// by the "waters" value ("shelf" next to land, "open" otherwise). Storms raise the sea state.
+var attributeModel = &model.Model{
+	Rules: []model.Rule{
+		model.Enum{
+			Name:  "behavior",
+			Given: "type",
+			Table: map[string]model.Weights{
+				"Cephalopod":  {"surfacing": 2, "shadowing a vessel": 2, "feeding": 3, "diving": 3},
+				"Sea Serpent": {"surfacing": 3, "circling": 3, "shadowing a vessel": 2, "breaching": 2},
+				"Leviathan":   {"surfacing": 2, "breaching": 3, "feeding": 2, "diving": 2, "shadowing a vessel": 1},
+				"Crustacean":  {"surfacing": 1, "feeding": 4, "circling": 1},
+				"Abyssal":     {"diving": 4, "surfacing": 1, "shadowing a vessel": 2},
+			},
+		},
+		model.Int{
+			Name:  "depth",
+			Given: "waters",
+			Table: map[string]model.Bounds{
+				"shelf": {Min: minDepth, Max: maxCoastalDepth},
+				"open":  {Min: maxCoastalDepth, Max: maxDepth},
+			},
+			Default: model.Bounds{Min: minDepth, Max: maxDepth},
+		},
+		model.Enum{
+			// Rough seas are reported less often than moderate ones, except in storms
+			Name:  "sea_state",
+			Given: "precipitation",
+			Table: map[string]model.Weights{
+				weather.PrecipHeavyRain:    {"moderate": 2, "rough": 4, "very rough": 4, "high": 2, "very high": 1},
+				weather.PrecipThunderstorm: {"rough": 2, "very rough": 4, "high": 4, "very high": 2, "phenomenal": 1},
+			},
+			Default: model.Weights{
+				"calm": 2, "smooth": 3, "slight": 4, "moderate": 4, "rough": 3,
+				"very rough": 2, "high": 1, "very high": 1, "phenomenal": 1,
+			},
+		},
+	},
+}
+
+// SetNameCheck installs a check reporting creature names already in use, so that
+// generated names stay unique.
+func (g *Generator) SetNameCheck(taken func(name string) bool) {
+	g.namer.SetTaken(taken)
+}
+
+// Category returns the creature category this generator handles.
+func (g *Generator) Category() string {
+	return "marine"
+}
+
+// Describe returns the metadata for the marine category.
+func (g *Generator) Describe() sighting.CategoryInfo {
+	return sighting.CategoryInfo{
+		DisplayName: "Marine",
+		Description: "Oceanic entities, shipping-lane threat level",
+		Criteria: "Entities observed at sea or along coastlines by vessels, coastal stations or sonar, " +
+			"exceeding the size or depth range of any catalogued marine species.",
+		Types: slices.Clone(g.types),
+		Attributes: sighting.Schema{
+			{
+				Name:        "behavior",
+				Label:       "Behavior",
+				Description: "Activity observed at the time of the sighting",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(g.behaviors),
+				Required:    true,
+			},
+			{
+				Name:        "depth",
+				Label:       "Water Depth",
+				Description: "Estimated depth of water beneath the sighting",
+				Type:        sighting.AttributeInt,
+				Unit:        "meters",
+				Min:         sighting.Bound(minDepth),
+				Max:         sighting.Bound(maxDepth),
+				Required:    true,
+			},
+			{
+				Name:        "sea_state",
+				Label:       "Sea State",
+				Description: "Surface conditions on the Douglas sea scale",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(seaStates),
+				Required:    true,
+			},
+			{
+				Name:        "nearest_port",
+				Label:       "Nearest Port",
+				Description: "Closest harbour to the sighting",
+				Type:        sighting.AttributeString,
+				Required:    true,
+			},
+			{
+				Name:        "port_distance",
+				Label:       "Distance to Port",
+				Description: "Great-circle distance to the nearest port",
+				Type:        sighting.AttributeInt,
+				Unit:        "km",
+				Min:         sighting.Bound(0.0),
+				Required:    true,
+			},
+		},
+	}
+}
+
+// AssessThreat rates sea creatures by the danger to shipping: leviathans and sea
+// serpents are elevated and others guarded, one level higher when shadowing a
+// vessel and one higher again within 50 km of port.
+func (g *Generator) AssessThreat(s *sighting.Sighting) sighting.Threat {
+	threat := sighting.ThreatGuarded
+	if s.Type == "Leviathan" || s.Type == "Sea Serpent" {
+		threat = sighting.ThreatElevated
+	}
+	if s.Attributes.Text("behavior") == "shadowing a vessel" {
+		threat = threat.Raise(1)
+	}
+	if distance, ok := s.Attributes.Number("port_distance"); ok && distance < 50 {
+		threat = threat.Raise(1)
+	}
+	return threat
+}
+
+// Generate creates a random sea creature sighting at sea or on a coastline.
+func (g *Generator) Generate() (*sighting.Sighting, error) {
+	return g.GenerateWithOptions(context.Background(), sighting.Options{})
+}
+
+// GenerateWithOptions creates a sea creature sighting honouring the requested region,
+// location, timestamp, type and seed. A requested location must be at sea or on a coastline.
+func (g *Generator) GenerateWithOptions(ctx context.Context, opts sighting.Options) (*sighting.Sighting, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rng := random.New(opts.Seed)
+
+	lat, lon, err := g.choosePosition(rng, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nearest, distance := g.nearestPort(lat, lon)
+
+	marineType := opts.Type
+	if marineType == "" {
+		marineType, err = rng.Choice(g.types)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate type: %w", err)
+		}
+	} else if !slices.Contains(g.types, marineType) {
+		return nil, fmt.Errorf("%w: unknown marine type %q", sighting.ErrInvalidOptions, marineType)
+	}
+
+	name, err := g.namer.Name(rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate name: %w", err)
+	}
+
+	// Waters next to land are shallow shelf; open ocean is deep
+	waters := "open"
+	if geo.NearLand(lat, lon) {
+		waters = "shelf"
+	}
+	timestamp := opts.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	conditions := weather.At(lat, lon, timestamp)
+
+	values := model.Values{"type": marineType, "waters": waters, "precipitation": conditions.Precipitation}
+	if err := g.model.Draw(rng, values); err != nil {
+		return nil, err
+	}
+
+	loc := sighting.Location{
+		Latitude:  lat,
+		Longitude: lon,
+		City:      nearest.sea,
+		Country:   nearest.country,
+		Region:    nearest.region,
+	}
+	if opts.Location != nil {
+		loc = *opts.Location
+	}
+
+	s := &sighting.Sighting{
+		ID:        fmt.Sprintf("marine-%d", time.Now().UnixNano()),
+		Name:      name,
+		Type:      marineType,
+		Category:  g.Category(),
+		Location:  loc,
+		Timestamp: timestamp,
+		Weather:   &conditions,
+		Attributes: sighting.Attributes{
+			"behavior":      values["behavior"],
+			"depth":         values["depth"],
+			"sea_state":     values["sea_state"],
+			"nearest_port":  nearest.name,
+			"port_distance": distance,
+		},
+	}
+
+	// Reports name the body of water even when the caller supplied bare coordinates
+	vars := narrative.SightingVars(s, g.Describe().Attributes)
+	vars.Set("place", nearest.sea)
+	s.Description, err = g.grammar.Generate(rng, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	count, err := rng.Int(1, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report count: %w", err)
+	}
+	s.Reports, err = witness.Reports(rng, s, g.Describe(), witness.Options{
+		Count:       count,
+		MinDistance: 50,
+		MaxDistance: 3000,
+		Details:     []string{"behavior", "sea_state"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// choosePosition returns the requested location if it is at sea or on a coastline,
+// or a random offshore point near a port in the requested region.
+func (g *Generator) choosePosition(rng *random.Source, opts sighting.Options) (float64, float64, error) {
+	if opts.Location != nil {
+		if !geo.NearSea(opts.Location.Latitude, opts.Location.Longitude) {
+			return 0, 0, fmt.Errorf("%w: location %.4f, %.4f is inland", sighting.ErrInvalidOptions,
+				opts.Location.Latitude, opts.Location.Longitude)
+		}
+		return opts.Location.Latitude, opts.Location.Longitude, nil
+	}
+
+	candidates := g.ports
+	if opts.Region != "" {
+		candidates = make([]port, 0, len(g.ports))
+		for _, p := range g.ports {
+			if strings.EqualFold(p.region, opts.Region) {
+				candidates = append(candidates, p)
+			}
+		}
+		if len(candidates) == 0 {
+			return 0, 0, fmt.Errorf("%w: no marine ports in region %q", sighting.ErrInvalidOptions, opts.Region)
+		}
+	}
+
+	for attempt := 0; attempt < maxPlacements; attempt++ {
+		idx, err := rng.Int(0, len(candidates)-1)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to choose port: %w", err)
+		}
+		bearing, err := rng.Float(0, 360)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to generate bearing: %w", err)
+		}
+		offshore, err := rng.Float(minOffshore, maxOffshore)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to generate distance: %w", err)
+		}
+
+		origin := candidates[idx]
+		lat, lon := geo.Destination(origin.lat, origin.lon, bearing, offshore)
+		if !geo.IsLand(lat, lon) {
+			return sighting.Round(lat, 4), sighting.Round(lon, 4), nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("failed to place sighting at sea after %d attempts", maxPlacements)
+}
+
+// nearestPort returns the closest port to the point and its distance in whole kilometres.
+func (g *Generator) nearestPort(lat, lon float64) (port, int) {
+	best, bestDistance := g.ports[0], math.Inf(1)
+	for _, p := range g.ports {
+		if d := geo.Distance(lat, lon, p.lat, p.lon); d < bestDistance {
+			best, bestDistance = p, d
+		}
+	}
+	return best, int(math.Round(bestDistance))
+}
@@ -0,0 +1,252 @@
+// Package model declares how a category's attributes depend on one another.
+// A Model is a list of rules drawn in order, where each rule may be conditioned on a
+// value drawn or supplied before it, plus requirements that rule out combinations
+// such as an Arctic creature in the tropics. Generators declare a model once and
+// draw every sighting's attributes from it.
+package model
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+	"github.com/pymk/creature-sighting/internal/geo"
+)
+
+// Values holds the attributes drawn so far along with any inputs the generator
+// supplied, such as the requested type or values derived from the location.
+type Values map[string]any
+
+// Weights gives the relative odds of each value of an enum. Values with zero odds,
+// or missing from the map, are never drawn.
+type Weights map[string]int
+
+// Bounds is an inclusive integer range.
+type Bounds struct {
+	Min int
+	Max int
+}
+
+// Rule draws a single value into a Values map.
+type Rule interface {
+	// Attribute returns the name of the value the rule draws.
+	Attribute() string
+
+	draw(rng *random.Source, m *Model, values Values) error
+}
+
+// Enum draws one of a fixed set of values. When Given is set, Table holds the odds
+// for each value of the Given attribute and Default applies to values it omits;
+// otherwise Default alone is used.
+type Enum struct {
+	Name    string
+	Given   string
+	Table   map[string]Weights
+	Default Weights
+}
+
+// Int draws an integer within bounds. When Given is set, Table holds the bounds for
+// each value of the Given attribute and Default applies to values it omits.
+type Int struct {
+	Name    string
+	Given   string
+	Table   map[string]Bounds
+	Default Bounds
+}
+
+// Requirement rules out combinations of values: whenever every If entry matches,
+// each Then attribute must take one of its listed values.
+type Requirement struct {
+	If   map[string]string
+	Then map[string][]string
+}
+
+// Model is an ordered set of rules and the requirements their results must satisfy.
+type Model struct {
+	Rules        []Rule
+	Requirements []Requirement
+}
+
+// Location returns the values rules and requirements may use to depend on where a
+// sighting takes place: "zone", the latitude band from geo.Zone; "latitude", which
+// is "high" from geo.HighLatitude towards either pole and "low" otherwise; and
+// "coast", which is "coastal" at sea or on a coastline and "inland" otherwise.
+func Location(lat, lon float64) Values {
+	coast := "inland"
+	if geo.NearSea(lat, lon) {
+		coast = "coastal"
+	}
+	latitude := "low"
+	if math.Abs(lat) >= geo.HighLatitude {
+		latitude = "high"
+	}
+	return Values{"zone": geo.Zone(lat), "latitude": latitude, "coast": coast}
+}
+
+// Merge returns a new Values holding the entries of every argument, later ones
+// taking precedence.
+func Merge(sets ...Values) Values {
+	merged := Values{}
+	for _, set := range sets {
+		for k, v := range set {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// Check reports the first requirement violated by values. Requirements that refer
+// to a value not yet present are not violated.
+func (m *Model) Check(values Values) error {
+	for _, req := range m.Requirements {
+		if !req.applies(values) {
+			continue
+		}
+		for _, name := range sortedKeys(req.Then) {
+			v, ok := values[name]
+			if !ok {
+				continue
+			}
+			allowed := req.Then[name]
+			if !slices.Contains(allowed, fmt.Sprint(v)) {
+				return fmt.Errorf("%s requires %s to be %s, not %v",
+					req.describe(), name, strings.Join(allowed, " or "), v)
+			}
+		}
+	}
+	return nil
+}
+
+// Permits reports whether values satisfy every requirement.
+func (m *Model) Permits(values Values) bool {
+	return m.Check(values) == nil
+}
+
+// Draw fills in every rule's value that is not already present, in rule order.
+// Values supplied by the caller are checked against the requirements first.
+func (m *Model) Draw(rng *random.Source, values Values) error {
+	if err := m.Check(values); err != nil {
+		return err
+	}
+	for _, rule := range m.Rules {
+		if _, ok := values[rule.Attribute()]; ok {
+			continue
+		}
+		if err := rule.draw(rng, m, values); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", rule.Attribute(), err)
+		}
+	}
+	return nil
+}
+
+// Attribute returns the name of the value the rule draws.
+func (e Enum) Attribute() string {
+	return e.Name
+}
+
+// Choices returns the values the rule can draw with non-zero odds given values,
+// in sorted order, leaving out any that would violate a requirement of m.
+func (e Enum) Choices(m *Model, values Values) ([]string, Weights) {
+	weights := e.Default
+	if e.Given != "" {
+		if w, ok := e.Table[fmt.Sprint(values[e.Given])]; ok {
+			weights = w
+		}
+	}
+
+	var choices []string
+	for _, v := range sortedKeys(weights) {
+		if weights[v] <= 0 {
+			continue
+		}
+		if m != nil && !m.Permits(Merge(values, Values{e.Name: v})) {
+			continue
+		}
+		choices = append(choices, v)
+	}
+	return choices, weights
+}
+
+// draw picks a value with probability proportional to its odds.
+func (e Enum) draw(rng *random.Source, m *Model, values Values) error {
+	choices, weights := e.Choices(m, values)
+	if len(choices) == 0 {
+		return fmt.Errorf("no permitted values")
+	}
+
+	total := 0
+	for _, v := range choices {
+		total += weights[v]
+	}
+	pick, err := rng.Int(1, total)
+	if err != nil {
+		return err
+	}
+	for _, v := range choices {
+		pick -= weights[v]
+		if pick <= 0 {
+			values[e.Name] = v
+			return nil
+		}
+	}
+	return nil
+}
+
+// Attribute returns the name of the value the rule draws.
+func (i Int) Attribute() string {
+	return i.Name
+}
+
+// Bounds returns the range the rule draws from given values.
+func (i Int) Bounds(values Values) Bounds {
+	if i.Given != "" {
+		if b, ok := i.Table[fmt.Sprint(values[i.Given])]; ok {
+			return b
+		}
+	}
+	return i.Default
+}
+
+// draw picks an integer uniformly within the applicable bounds.
+func (i Int) draw(rng *random.Source, _ *Model, values Values) error {
+	b := i.Bounds(values)
+	n, err := rng.Int(b.Min, b.Max)
+	if err != nil {
+		return err
+	}
+	values[i.Name] = n
+	return nil
+}
+
+// applies reports whether every If entry of the requirement matches values.
+func (r Requirement) applies(values Values) bool {
+	for name, want := range r.If {
+		v, ok := values[name]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// describe renders the requirement's condition, as in "type Arctic".
+func (r Requirement) describe() string {
+	parts := make([]string, 0, len(r.If))
+	for _, name := range sortedKeys(r.If) {
+		parts = append(parts, name+" "+r.If[name])
+	}
+	return strings.Join(parts, " and ")
+}
+
+// sortedKeys returns the keys of m in sorted order, so that draws from a seeded
+// source do not depend on map iteration order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+// Package random provides the random source shared by creature generators.
+// Unseeded sources draw from crypto/rand for unpredictability; seeded sources use a
+// deterministic PCG stream so the same seed always yields the same sighting.
+package random
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	mathrand "math/rand/v2"
+)
+
+// Source supplies the random choices for a single generation run.
+type Source struct {
+	seeded *mathrand.Rand
+}
+
+// New returns a crypto-backed source, or a deterministic one when seed is set.
+func New(seed *int64) *Source {
+	if seed == nil {
+		return &Source{}
+	}
+	return &Source{seeded: mathrand.New(mathrand.NewPCG(uint64(*seed), 0))}
+}
+
+// Seeded reports whether the source is deterministic.
+func (s *Source) Seeded() bool {
+	return s.seeded != nil
+}
+
+// Choice selects a random string from the provided choices slice.
+func (s *Source) Choice(choices []string) (string, error) {
+	if len(choices) == 0 {
+		return "", fmt.Errorf("empty choices")
+	}
+
+	idx, err := s.Int(0, len(choices)-1)
+	if err != nil {
+		return "", err
+	}
+
+	return choices[idx], nil
+}
+
+// Int generates a random integer in the range [min, max].
+// Unseeded sources use crypto/rand for unpredictability rather than math/rand.
+func (s *Source) Int(min, max int) (int, error) {
+	if min > max {
+		return 0, fmt.Errorf("min cannot be greater than max")
+	}
+
+	if s.seeded != nil {
+		return s.seeded.IntN(max-min+1) + min, nil
+	}
+
+	// Use crypto/rand for security - prevents predictable sequences
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n.Int64()) + min, nil
+}
+
+// Float generates a random float in the range [min, max).
+func (s *Source) Float(min, max float64) (float64, error) {
+	if min > max {
+		return 0, fmt.Errorf("min cannot be greater than max")
+	}
+
+	if s.seeded != nil {
+		return min + s.seeded.Float64()*(max-min), nil
+	}
+
+	// 53 random bits give a uniformly distributed float64 in [0, 1)
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<53))
+	if err != nil {
+		return 0, err
+	}
+
+	return min + float64(n.Int64())/(1<<53)*(max-min), nil
+}
@@ -0,0 +1,334 @@
+// Package ufo implements a generator for unidentified aerial phenomenon sightings.
+// Each sighting records the object's shape, lights and flight characteristics along with
+// the start and end of its observed flight path.
+package ufo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+	"github.com/pymk/creature-sighting/internal/creatures/witness"
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/narrative"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/weather"
+)
+
+// Flight characteristic bounds for generated sightings.
+const (
+	minAltitude     = 100   // meters
+	maxAltitude     = 15000 // meters
+	minSpeed        = 20    // km/h
+	maxSpeed        = 5000  // km/h
+	minDuration     = 5     // seconds
+	maxDuration     = 1800  // seconds
+	minObjects      = 1
+	maxObjects      = 12
+	maxVisibleTrack = 150.0 // km an observer can plausibly follow an object
+)
+
+// Generator creates random aerial phenomenon sightings with flight-path data.
+type Generator struct {
+	grammar       *narrative.Grammar
+	designations  []string
+	types         []string
+	shapes        []string
+	lightPatterns []string
+	locations     []sighting.Location
+}
+
+// NewGenerator creates a new UFO generator with predefined sighting data.
+func NewGenerator() *Generator {
+	return &Generator{
+		grammar: narrative.MustLoad("ufo"),
+		designations: []string{
+			"Object", "Contact", "Track", "Anomaly", "Bogey",
+		},
+		types: []string{
+			"Nocturnal Light", "Daylight Disc", "Radar-Visual", "Close Encounter",
+		},
+		shapes: []string{
+			"disc", "triangle", "sphere", "cigar", "boomerang", "orb", "tic-tac",
+		},
+		lightPatterns: []string{
+			"steady", "pulsing", "strobing", "rotating", "color-shifting", "none",
+		},
+		locations: []sighting.Location{
+			{Latitude: 33.3943, Longitude: -104.5230, City: "Roswell", Country: "USA", Region: "North America"},
+			{Latitude: 33.4484, Longitude: -112.0740, City: "Phoenix", Country: "USA", Region: "North America"},
+			{Latitude: 41.9742, Longitude: -87.9073, City: "Chicago", Country: "USA", Region: "North America"},
+			{Latitude: 43.5700, Longitude: -65.7100, City: "Shag Harbour", Country: "Canada", Region: "North America"},
+			{Latitude: 52.0870, Longitude: 1.4340, City: "Rendlesham Forest", Country: "UK", Region: "Europe"},
+			{Latitude: 62.7936, Longitude: 11.1853, City: "Hessdalen", Country: "Norway", Region: "Europe"},
+			{Latitude: -21.5517, Longitude: -45.4303, City: "Varginha", Country: "Brazil", Region: "South America"},
+			{Latitude: -37.9260, Longitude: 145.1420, City: "Westall", Country: "Australia", Region: "Oceania"},
+			{Latitude: -42.4000, Longitude: 173.6800, City: "Kaikoura", Country: "New Zealand", Region: "Oceania"},
+			{Latitude: 35.6892, Longitude: 51.3890, City: "Tehran", Country: "Iran", Region: "Asia"},
+		},
+	}
+}
+
+// Category returns the creature category this generator handles.
+func (g *Generator) Category() string {
+	return "ufo"
+}
+
+// Describe returns the metadata for the UFO category.
+func (g *Generator) Describe() sighting.CategoryInfo {
+	return sighting.CategoryInfo{
+		DisplayName: "Aerial Phenomenon",
+		Description: "Unidentified aerial objects, airspace threat level",
+		Criteria: "Airborne objects displaying flight characteristics inconsistent with known aircraft, " +
+			"tracked visually or by radar along an observable flight path.",
+		Types: slices.Clone(g.types),
+		Attributes: sighting.Schema{
+			{
+				Name:        "shape",
+				Label:       "Shape",
+				Description: "Apparent outline of the object",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(g.shapes),
+				Required:    true,
+			},
+			{
+				Name:        "light_pattern",
+				Label:       "Light Pattern",
+				Description: "Behavior of visible lights on the object",
+				Type:        sighting.AttributeEnum,
+				Values:      slices.Clone(g.lightPatterns),
+				Required:    true,
+			},
+			{
+				Name:        "object_count",
+				Label:       "Objects",
+				Description: "Number of objects observed together",
+				Type:        sighting.AttributeInt,
+				Min:         sighting.Bound(minObjects),
+				Max:         sighting.Bound(maxObjects),
+				Required:    true,
+			},
+			{
+				Name:        "altitude",
+				Label:       "Estimated Altitude",
+				Description: "Estimated height above ground",
+				Type:        sighting.AttributeInt,
+				Unit:        "meters",
+				Min:         sighting.Bound(minAltitude),
+				Max:         sighting.Bound(maxAltitude),
+				Required:    true,
+			},
+			{
+				Name:        "speed",
+				Label:       "Speed",
+				Description: "Estimated ground speed",
+				Type:        sighting.AttributeInt,
+				Unit:        "km/h",
+				Min:         sighting.Bound(minSpeed),
+				Max:         sighting.Bound(maxSpeed),
+				Required:    true,
+			},
+			{
+				Name:        "heading",
+				Label:       "Heading",
+				Description: "Direction of travel, clockwise from north",
+				Type:        sighting.AttributeInt,
+				Unit:        "degrees",
+				Min:         sighting.Bound(0.0),
+				Max:         sighting.Bound(359.0),
+				Required:    true,
+			},
+			{
+				Name:        "duration",
+				Label:       "Duration",
+				Description: "Time the object remained in view",
+				Type:        sighting.AttributeInt,
+				Unit:        "seconds",
+				Min:         sighting.Bound(minDuration),
+				Max:         sighting.Bound(maxDuration),
+				Required:    true,
+			},
+		},
+	}
+}
+
+// AssessThreat rates aerial phenomena by how close the encounter was: close
+// encounters are elevated, radar-visual contacts guarded and lights in the sky
+// low. Formations of five or more objects raise the level by one.
+func (g *Generator) AssessThreat(s *sighting.Sighting) sighting.Threat {
+	threat := sighting.ThreatLow
+	switch s.Type {
+	case "Close Encounter":
+		threat = sighting.ThreatElevated
+	case "Radar-Visual":
+		threat = sighting.ThreatGuarded
+	}
+	if count, ok := s.Attributes.Number("object_count"); ok && count >= 5 {
+		threat = threat.Raise(1)
+	}
+	return threat
+}
+
+// Generate creates a random aerial sighting with a flight path.
+func (g *Generator) Generate() (*sighting.Sighting, error) {
+	return g.GenerateWithOptions(context.Background(), sighting.Options{})
+}
+
+// GenerateWithOptions creates an aerial sighting honouring the requested region, location,
+// timestamp, type and seed. The requested location is where the object was first seen.
+func (g *Generator) GenerateWithOptions(ctx context.Context, opts sighting.Options) (*sighting.Sighting, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rng := random.New(opts.Seed)
+
+	start, err := g.chooseLocation(rng, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ufoType := opts.Type
+	if ufoType == "" {
+		ufoType, err = rng.Choice(g.types)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate type: %w", err)
+		}
+	} else if !slices.Contains(g.types, ufoType) {
+		return nil, fmt.Errorf("%w: unknown ufo type %q", sighting.ErrInvalidOptions, ufoType)
+	}
+
+	designation, err := rng.Choice(g.designations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate designation: %w", err)
+	}
+
+	shape, err := rng.Choice(g.shapes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate shape: %w", err)
+	}
+
+	lights, err := rng.Choice(g.lightPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate light pattern: %w", err)
+	}
+
+	objects, err := rng.Int(minObjects, maxObjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate object count: %w", err)
+	}
+
+	altitude, err := rng.Int(minAltitude, maxAltitude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate altitude: %w", err)
+	}
+
+	speed, err := rng.Int(minSpeed, maxSpeed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate speed: %w", err)
+	}
+
+	heading, err := rng.Int(0, 359)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate heading: %w", err)
+	}
+
+	// Faster objects leave the observer's view sooner
+	longest := min(maxDuration, int(maxVisibleTrack*3600/float64(speed)))
+	duration, err := rng.Int(minDuration, max(minDuration, longest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate duration: %w", err)
+	}
+
+	distance := float64(speed) * float64(duration) / 3600
+	endLat, endLon := geo.Destination(start.Latitude, start.Longitude, float64(heading), distance)
+	end := sighting.Location{
+		Latitude:  sighting.Round(endLat, 4),
+		Longitude: sighting.Round(endLon, 4),
+		Region:    start.Region,
+	}
+
+	timestamp := opts.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	s := &sighting.Sighting{
+		ID:        fmt.Sprintf("ufo-%d", time.Now().UnixNano()),
+		Name:      fmt.Sprintf("%s %s-%03d", strings.ToUpper(shape[:1])+shape[1:], designation, heading),
+		Type:      ufoType,
+		Category:  g.Category(),
+		Location:  start,
+		Path:      []sighting.Location{start, end},
+		Timestamp: timestamp,
+		Attributes: sighting.Attributes{
+			"shape":         shape,
+			"light_pattern": lights,
+			"object_count":  objects,
+			"altitude":      altitude,
+			"speed":         speed,
+			"heading":       heading,
+			"duration":      duration,
+		},
+	}
+
+	conditions := weather.At(start.Latitude, start.Longitude, timestamp)
+	s.Weather = &conditions
+
+	vars := narrative.SightingVars(s, g.Describe().Attributes)
+	vars.Set("compass", geo.CompassPoint(float64(heading)))
+	vars.Set("multiplicity", "single")
+	if objects > 1 {
+		vars.Set("multiplicity", "group")
+	}
+	s.Description, err = g.grammar.Generate(rng, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	count, err := rng.Int(1, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report count: %w", err)
+	}
+	s.Reports, err = witness.Reports(rng, s, g.Describe(), witness.Options{
+		Count:       count,
+		MinDistance: 300,
+		MaxDistance: 20000,
+		Details:     []string{"shape", "light_pattern", "object_count", "altitude", "speed", "duration"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// chooseLocation returns the caller-supplied location, or a random known hotspot
+// restricted to the requested region when one is given.
+func (g *Generator) chooseLocation(rng *random.Source, opts sighting.Options) (sighting.Location, error) {
+	if opts.Location != nil {
+		return *opts.Location, nil
+	}
+
+	candidates := g.locations
+	if opts.Region != "" {
+		candidates = make([]sighting.Location, 0, len(g.locations))
+		for _, loc := range g.locations {
+			if strings.EqualFold(loc.Region, opts.Region) {
+				candidates = append(candidates, loc)
+			}
+		}
+		if len(candidates) == 0 {
+			return sighting.Location{}, fmt.Errorf("%w: no ufo locations in region %q", sighting.ErrInvalidOptions, opts.Region)
+		}
+	}
+
+	idx, err := rng.Int(0, len(candidates)-1)
+	if err != nil {
+		return sighting.Location{}, fmt.Errorf("failed to generate location: %w", err)
+	}
+	return candidates[idx], nil
+}
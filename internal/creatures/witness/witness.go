@@ -0,0 +1,290 @@
+// Package witness writes several eyewitness reports for a generated sighting.
+// Each witness sees the creature from a different distance and misjudges some of
+// its details, so their accounts disagree with each other and with the record.
+package witness
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+	"github.com/pymk/creature-sighting/internal/narrative"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/weather"
+)
+
+// grammar writes the text of every report.
+var grammar = narrative.MustLoad("witness")
+
+// Witness names, combined as first name and surname initial.
+var (
+	firstNames = []string{
+		"Alex", "Amara", "Ben", "Carmen", "Dana", "Eli", "Farah", "Gus", "Hana", "Ivan",
+		"Jo", "Kenji", "Lena", "Marco", "Nadia", "Owen", "Priya", "Rosa", "Sam", "Tomas",
+	}
+	initials = []string{"A", "B", "C", "D", "F", "G", "H", "K", "L", "M", "N", "O", "P", "R", "S", "T", "W"}
+)
+
+// Options controls how many reports are written and how close the witnesses were.
+type Options struct {
+	Count       int      // number of reports
+	MinDistance int      // meters
+	MaxDistance int      // meters
+	Details     []string // attributes witnesses estimate for themselves
+}
+
+// Reports writes opts.Count reports of s. Numeric details are misjudged by up to a
+// third, more so by less confident witnesses, and enum details and the creature's
+// type are occasionally mistaken for others from info.
+func Reports(rng *random.Source, s *sighting.Sighting, info sighting.CategoryInfo, opts Options) ([]sighting.Report, error) {
+	reports := make([]sighting.Report, 0, opts.Count)
+	for range opts.Count {
+		r, err := report(rng, s, info, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate witness report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+
+	// Number the reports in the order they were filed, as storage does
+	slices.SortStableFunc(reports, func(a, b sighting.Report) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	for i := range reports {
+		reports[i].ID = fmt.Sprintf("%s-report-%d", s.ID, i+1)
+	}
+	return reports, nil
+}
+
+// report writes a single witness's account.
+func report(rng *random.Source, s *sighting.Sighting, info sighting.CategoryInfo, opts Options) (sighting.Report, error) {
+	name, err := witnessName(rng)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+
+	distance, err := rng.Int(opts.MinDistance, opts.MaxDistance)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+
+	confidence, err := assessConfidence(rng, distance, opts, s.Weather)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+
+	delay, err := rng.Int(10, 72*60)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+
+	vars := narrative.SightingVars(s, info.Attributes)
+	vars.Set("distance", formatDistance(distance))
+	vars.Set("confidence", confidence)
+
+	estimates, err := estimate(rng, s, info, opts.Details, confidence)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+	vars.Set("estimates", estimates)
+
+	doubt, err := doubtType(rng, s, info, vars)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+	vars.Set("doubt", doubt)
+
+	text, err := grammar.Generate(rng, vars)
+	if err != nil {
+		return sighting.Report{}, err
+	}
+
+	// Witnesses of a recent sighting cannot have come forward in the future
+	filed := s.Timestamp.Add(time.Duration(delay) * time.Minute)
+	if now := time.Now(); filed.After(now) && !s.Timestamp.After(now) {
+		filed = now
+	}
+
+	return sighting.Report{
+		Witness:    name,
+		Distance:   distance,
+		Confidence: confidence,
+		Text:       text,
+		Timestamp:  filed,
+	}, nil
+}
+
+// witnessName returns a random witness name, or an empty name for one in four
+// witnesses who prefer to stay anonymous.
+func witnessName(rng *random.Source) (string, error) {
+	anonymous, err := rng.Int(1, 4)
+	if err != nil || anonymous == 1 {
+		return "", err
+	}
+
+	first, err := rng.Choice(firstNames)
+	if err != nil {
+		return "", err
+	}
+	initial, err := rng.Choice(initials)
+	if err != nil {
+		return "", err
+	}
+	return first + " " + initial + ".", nil
+}
+
+// assessConfidence rates a witness from how close they were, downgraded when the
+// creature was beyond the visibility or it was dark.
+func assessConfidence(rng *random.Source, distance int, opts Options, w *weather.Conditions) (string, error) {
+	span := max(opts.MaxDistance-opts.MinDistance, 1)
+	jitter, err := rng.Float(0, 0.3)
+	if err != nil {
+		return "", err
+	}
+	score := float64(distance-opts.MinDistance)/float64(span) + jitter
+
+	if w != nil {
+		if float64(distance) > w.Visibility*1000 {
+			return "low", nil
+		}
+		if w.Daylight == weather.Night {
+			score += 0.3
+		}
+	}
+
+	switch {
+	case score < 0.45:
+		return "high", nil
+	case score < 0.9:
+		return "moderate", nil
+	default:
+		return "low", nil
+	}
+}
+
+// estimate writes the witness's own estimates of one or two of the detail attributes.
+func estimate(rng *random.Source, s *sighting.Sighting, info sighting.CategoryInfo, details []string, confidence string) (string, error) {
+	if len(details) == 0 {
+		return "", nil
+	}
+
+	count, err := rng.Int(1, min(2, len(details)))
+	if err != nil {
+		return "", err
+	}
+	order := slices.Clone(details)
+	for i := len(order) - 1; i > 0; i-- {
+		j, err := rng.Int(0, i)
+		if err != nil {
+			return "", err
+		}
+		order[i], order[j] = order[j], order[i]
+	}
+
+	var text string
+	for _, name := range order[:count] {
+		field, ok := info.Attributes.Field(name)
+		value, present := s.Attributes[name]
+		if !ok || !present {
+			continue
+		}
+
+		perceived, err := misjudge(rng, field, value, confidence)
+		if err != nil {
+			return "", err
+		}
+
+		kind := "word"
+		if field.Type == sighting.AttributeInt || field.Type == sighting.AttributeFloat {
+			kind = "number"
+		}
+		sentence, err := grammar.Expand(rng, "estimate_"+kind, narrative.Vars{
+			"label": {field.DisplayLabel()},
+			"value": {field.Format(perceived)},
+		})
+		if err != nil {
+			return "", err
+		}
+		text += " " + sentence
+	}
+	return text, nil
+}
+
+// misjudge returns the value as a witness perceived it. Numbers are off by up to
+// 10% for confident witnesses and 35% for unsure ones; enums are mistaken for
+// another value one time in three.
+func misjudge(rng *random.Source, field sighting.AttributeField, value any, confidence string) (any, error) {
+	switch field.Type {
+	case sighting.AttributeInt, sighting.AttributeFloat:
+		v, ok := number(value)
+		if !ok {
+			return value, nil
+		}
+		spread := map[string]float64{"high": 0.1, "moderate": 0.2, "low": 0.35}[confidence]
+		factor, err := rng.Float(1-spread, 1+spread)
+		if err != nil {
+			return nil, err
+		}
+		v *= factor
+		if field.Min != nil {
+			v = math.Max(v, *field.Min)
+		}
+		if field.Max != nil {
+			v = math.Min(v, *field.Max)
+		}
+		if field.Type == sighting.AttributeInt {
+			return int(math.Round(v)), nil
+		}
+		return math.Round(v*10) / 10, nil
+
+	case sighting.AttributeEnum:
+		mistaken, err := rng.Int(1, 3)
+		if err != nil || mistaken != 1 || len(field.Values) < 2 {
+			return value, err
+		}
+		return rng.Choice(field.Values)
+	}
+	return value, nil
+}
+
+// doubtType has one witness in four disagree about the creature's type.
+func doubtType(rng *random.Source, s *sighting.Sighting, info sighting.CategoryInfo, vars narrative.Vars) (string, error) {
+	others := slices.DeleteFunc(slices.Clone(info.Types), func(t string) bool { return t == s.Type })
+	if len(others) == 0 {
+		return "", nil
+	}
+
+	doubt, err := rng.Int(1, 4)
+	if err != nil || doubt != 1 {
+		return "", err
+	}
+	guess, err := rng.Choice(others)
+	if err != nil {
+		return "", err
+	}
+	vars.Set("type_guess", guess)
+	return grammar.Expand(rng, "type_doubt", vars)
+}
+
+// formatDistance renders a distance in meters, switching to kilometres past 1000.
+func formatDistance(meters int) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%d meters", meters)
+	}
+	return fmt.Sprintf("%.1f km", float64(meters)/1000)
+}
+
+// number converts an attribute value to float64.
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
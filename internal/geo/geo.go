@@ -0,0 +1,134 @@
+// Package geo provides great-circle calculations on latitude/longitude coordinates.
+// All distances are in kilometres and all bearings in degrees clockwise from north.
+package geo
+
+import (
+	"math"
+	"time"
+)
+
+// EarthRadiusKm is the mean radius of the Earth in kilometres.
+const EarthRadiusKm = 6371.0
+
+// Distance returns the great-circle distance between two points using the haversine formula.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi := radians(lat2 - lat1)
+	dLambda := radians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Destination returns the point reached by travelling distanceKm from (lat, lon)
+// along the initial bearing.
+func Destination(lat, lon, bearing, distanceKm float64) (float64, float64) {
+	phi1, lambda1 := radians(lat), radians(lon)
+	theta := radians(bearing)
+	delta := distanceKm / EarthRadiusKm
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(
+		math.Sin(theta)*math.Sin(delta)*math.Cos(phi1),
+		math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2),
+	)
+
+	return degrees(phi2), NormalizeLongitude(degrees(lambda2))
+}
+
+// NormalizeLongitude wraps a longitude into the range [-180, 180).
+func NormalizeLongitude(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// CompassPoint returns the eight-point compass direction for a bearing, e.g. "NE".
+func CompassPoint(bearing float64) string {
+	points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	idx := int(math.Round(math.Mod(math.Mod(bearing, 360)+360, 360)/45)) % len(points)
+	return points[idx]
+}
+
+// LocalSolarHour returns the mean solar time at the longitude as fractional hours in
+// [0, 24). Noon is when the sun is highest, regardless of civil time zones.
+func LocalSolarHour(t time.Time, lon float64) float64 {
+	utc := t.UTC()
+	hour := float64(utc.Hour()) + float64(utc.Minute())/60 + float64(utc.Second())/3600
+	return math.Mod(math.Mod(hour+lon/15, 24)+24, 24)
+}
+
+// Latitude zones returned by Zone.
+const (
+	ZoneTropical  = "tropical"
+	ZoneTemperate = "temperate"
+	ZoneSubpolar  = "subpolar"
+	ZonePolar     = "polar"
+)
+
+// HighLatitude is the latitude, north or south, from which a place counts as high
+// latitude ground: north of the great cities of the subpolar band, such as London,
+// Berlin and Vancouver.
+const HighLatitude = 60.0
+
+// Zone classifies a latitude into a climate band: tropical inside the tropics,
+// polar inside the polar circles, and subpolar from 50 degrees to the circles.
+func Zone(lat float64) string {
+	switch abs := math.Abs(lat); {
+	case abs < 23.44:
+		return ZoneTropical
+	case abs < 50:
+		return ZoneTemperate
+	case abs < 66.56:
+		return ZoneSubpolar
+	default:
+		return ZonePolar
+	}
+}
+
+// Point is a position given as latitude and longitude.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// InPolygon reports whether (lat, lon) lies inside the polygon whose vertices
+// are given in order, using the even-odd rule on a flat latitude/longitude plane.
+// The polygon closes itself, and edges take the short way round, so polygons may
+// cross the antimeridian.
+func InPolygon(lat, lon float64, polygon []Point) bool {
+	if len(polygon) < 3 {
+		return false
+	}
+
+	// Unwrap longitudes so that no edge jumps across the antimeridian
+	lons := make([]float64, len(polygon))
+	lons[0] = polygon[0].Lon
+	for i := 1; i < len(polygon); i++ {
+		lons[i] = lons[i-1] + NormalizeLongitude(polygon[i].Lon-polygon[i-1].Lon)
+	}
+
+	x := polygon[0].Lon + NormalizeLongitude(lon-polygon[0].Lon)
+	for _, x := range []float64{x, x - 360, x + 360} {
+		inside := false
+		for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+			yi, yj := polygon[i].Lat, polygon[j].Lat
+			if (yi > lat) != (yj > lat) && x < lons[i]+(lat-yi)*(lons[j]-lons[i])/(yj-yi) {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+// radians converts degrees to radians.
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// degrees converts radians to degrees.
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,71 @@
+package geo
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// landmaskData is a coarse 1-degree land/sea raster. Each of its 180 lines is a
+// band of latitude from 90°N southwards, and each of the 360 characters in a line
+// is a cell of longitude from 180°W eastwards: '#' marks land and '.' marks sea.
+// Inland seas such as the Black Sea and the Caspian are sea; lakes are land.
+//
+//go:embed landmask.txt
+var landmaskData string
+
+// Raster dimensions of the embedded land mask.
+const (
+	maskRows = 180
+	maskCols = 360
+)
+
+// landmask holds the parsed raster rows.
+var landmask = strings.Fields(landmaskData)
+
+// IsLand reports whether the mask cell containing the point is land.
+func IsLand(lat, lon float64) bool {
+	row, col := maskCell(lat, lon)
+	return landAt(row, col)
+}
+
+// NearSea reports whether the point lies at sea or on a coastline, that is, whether
+// its mask cell or any adjacent cell is sea.
+func NearSea(lat, lon float64) bool {
+	return anyNeighbour(lat, lon, false)
+}
+
+// NearLand reports whether the point lies on land or in coastal waters, that is,
+// whether its mask cell or any adjacent cell is land.
+func NearLand(lat, lon float64) bool {
+	return anyNeighbour(lat, lon, true)
+}
+
+// anyNeighbour reports whether the cell containing the point, or one of its eight
+// neighbours, has the requested land value. Longitude wraps around the antimeridian.
+func anyNeighbour(lat, lon float64, land bool) bool {
+	row, col := maskCell(lat, lon)
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			r := row + dr
+			if r < 0 || r >= maskRows {
+				continue
+			}
+			if landAt(r, (col+dc+maskCols)%maskCols) == land {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maskCell returns the raster row and column containing the point.
+func maskCell(lat, lon float64) (int, int) {
+	row := min(max(int(90-lat), 0), maskRows-1)
+	col := min(max(int(NormalizeLongitude(lon)+180), 0), maskCols-1)
+	return row, col
+}
+
+// landAt reports whether the raster cell is land.
+func landAt(row, col int) bool {
+	return landmask[row][col] == '#'
+}
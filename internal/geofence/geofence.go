@@ -0,0 +1,120 @@
+// Package geofence watches named areas for new sightings. A geofence is a circle
+// or a polygon over latitude and longitude; every sighting stored inside one
+// raises an alert. Geofences are saved to a file; alerts live in memory.
+package geofence
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// Errors returned when managing geofences.
+var (
+	ErrInvalidGeofence = errors.New("invalid geofence")
+	ErrNotFound        = errors.New("geofence not found")
+)
+
+// Limits on the geofences that can be defined.
+const (
+	maxNameLength  = 100
+	maxVertices    = 1000
+	maxRadiusKm    = geo.EarthRadiusKm * math.Pi // half way round the globe
+	minPolygonSize = 3
+)
+
+// Geofence is a named area watched for sightings. Exactly one of Circle and
+// Polygon is set.
+type Geofence struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Circle  *Circle     `json:"circle,omitempty"`
+	Polygon []geo.Point `json:"polygon,omitempty"`
+	Owner   string      `json:"owner"`
+	Created time.Time   `json:"created"`
+	Updated time.Time   `json:"updated"`
+}
+
+// Circle is the area within a great-circle distance of its center.
+type Circle struct {
+	Center   geo.Point `json:"center"`
+	RadiusKm float64   `json:"radius_km"`
+}
+
+// Alert records a sighting stored inside a geofence. It keeps the geofence's name
+// and the sighting's summary so that it still reads sensibly once either is gone.
+type Alert struct {
+	ID           string          `json:"id"`
+	Geofence     string          `json:"geofence"`
+	GeofenceName string          `json:"geofence_name"`
+	SightingID   string          `json:"sighting_id"`
+	SightingName string          `json:"sighting_name"`
+	Category     string          `json:"category"`
+	Threat       sighting.Threat `json:"threat,omitempty"`
+	Location     geo.Point       `json:"location"`
+	Triggered    time.Time       `json:"triggered"`
+}
+
+// Shape returns "circle" or "polygon".
+func (g Geofence) Shape() string {
+	if g.Circle != nil {
+		return "circle"
+	}
+	return "polygon"
+}
+
+// Contains reports whether the point lies inside the geofence.
+func (g Geofence) Contains(lat, lon float64) bool {
+	if g.Circle != nil {
+		return geo.Distance(g.Circle.Center.Lat, g.Circle.Center.Lon, lat, lon) <= g.Circle.RadiusKm
+	}
+	return geo.InPolygon(lat, lon, g.Polygon)
+}
+
+// Check verifies that the geofence has a name and exactly one well-formed shape.
+func (g Geofence) Check() error {
+	name := strings.TrimSpace(g.Name)
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidGeofence)
+	case len(name) > maxNameLength:
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidGeofence, maxNameLength)
+	case g.Circle == nil && len(g.Polygon) == 0:
+		return fmt.Errorf("%w: a circle or a polygon is required", ErrInvalidGeofence)
+	case g.Circle != nil && len(g.Polygon) > 0:
+		return fmt.Errorf("%w: give a circle or a polygon, not both", ErrInvalidGeofence)
+	}
+
+	if g.Circle != nil {
+		if err := checkPoint(g.Circle.Center); err != nil {
+			return err
+		}
+		if g.Circle.RadiusKm <= 0 || g.Circle.RadiusKm > maxRadiusKm {
+			return fmt.Errorf("%w: radius_km must be between 0 and %.0f", ErrInvalidGeofence, maxRadiusKm)
+		}
+		return nil
+	}
+
+	if len(g.Polygon) < minPolygonSize || len(g.Polygon) > maxVertices {
+		return fmt.Errorf("%w: a polygon needs %d to %d vertices", ErrInvalidGeofence, minPolygonSize, maxVertices)
+	}
+	for _, p := range g.Polygon {
+		if err := checkPoint(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPoint verifies that p lies on the globe.
+func checkPoint(p geo.Point) error {
+	if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("%w: point %g,%g is off the globe", ErrInvalidGeofence, p.Lat, p.Lon)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package geofence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// maxAlerts is how many alerts are kept; older ones are dropped.
+const maxAlerts = 1000
+
+// Store holds the geofences, persisted as JSON to a file, and the alerts they
+// have raised. Changes to geofences are written through immediately.
+type Store struct {
+	mu        sync.RWMutex
+	path      string
+	geofences []Geofence
+	alerts    []Alert
+}
+
+// Open loads the geofences at path. A missing file is treated as having no
+// geofences and created on the first change.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geofences: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.geofences); err != nil {
+		return nil, fmt.Errorf("failed to parse geofences in %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// List returns every geofence, in the order they were added.
+func (s *Store) List() []Geofence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.geofences)
+}
+
+// Get returns the geofence with the given ID.
+func (s *Store) Get(id string) (Geofence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.index(id)
+	if i < 0 {
+		return Geofence{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return s.geofences[i], nil
+}
+
+// Add validates g and stores it under a new ID on behalf of owner, returning the
+// stored geofence.
+func (s *Store) Add(g Geofence, owner string) (Geofence, error) {
+	if err := g.Check(); err != nil {
+		return Geofence{}, err
+	}
+	now := time.Now().UTC()
+	g.ID, g.Name, g.Owner, g.Created, g.Updated = newID(), strings.TrimSpace(g.Name), owner, now, now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.geofences = append(s.geofences, g)
+	if err := s.save(); err != nil {
+		s.geofences = s.geofences[:len(s.geofences)-1]
+		return Geofence{}, err
+	}
+	return g, nil
+}
+
+// Update replaces the name and shape of the geofence with the given ID, keeping
+// its owner and creation time, and returns the updated geofence.
+func (s *Store) Update(id string, g Geofence) (Geofence, error) {
+	if err := g.Check(); err != nil {
+		return Geofence{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return Geofence{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	previous := s.geofences[i]
+	updated := previous
+	updated.Name, updated.Circle, updated.Polygon, updated.Updated = strings.TrimSpace(g.Name), g.Circle, g.Polygon, time.Now().UTC()
+
+	s.geofences[i] = updated
+	if err := s.save(); err != nil {
+		s.geofences[i] = previous
+		return Geofence{}, err
+	}
+	return updated, nil
+}
+
+// Remove deletes the geofence with the given ID and returns it. Alerts it has
+// already raised are kept.
+func (s *Store) Remove(id string) (Geofence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return Geofence{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	removed := s.geofences[i]
+	previous := s.geofences
+	s.geofences = slices.Delete(slices.Clone(s.geofences), i, i+1)
+	if err := s.save(); err != nil {
+		s.geofences = previous
+		return Geofence{}, err
+	}
+	return removed, nil
+}
+
+// Evaluate raises an alert for every geofence containing the sighting's
+// location. It is meant to be registered with storage.Store.OnAdd.
+func (s *Store) Evaluate(sg sighting.Sighting) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, g := range s.geofences {
+		if !g.Contains(sg.Location.Latitude, sg.Location.Longitude) {
+			continue
+		}
+		s.alerts = append(s.alerts, Alert{
+			ID:           newID(),
+			Geofence:     g.ID,
+			GeofenceName: g.Name,
+			SightingID:   sg.ID,
+			SightingName: sg.Name,
+			Category:     sg.Category,
+			Threat:       sg.Threat,
+			Location:     geo.Point{Lat: sg.Location.Latitude, Lon: sg.Location.Longitude},
+			Triggered:    now,
+		})
+	}
+	if over := len(s.alerts) - maxAlerts; over > 0 {
+		s.alerts = slices.Delete(s.alerts, 0, over)
+	}
+}
+
+// Alerts returns the alerts raised by the geofence with the given ID, or by
+// every geofence if id is empty, newest first.
+func (s *Store) Alerts(id string) []Alert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var alerts []Alert
+	for i := len(s.alerts) - 1; i >= 0; i-- {
+		if id == "" || s.alerts[i].Geofence == id {
+			alerts = append(alerts, s.alerts[i])
+		}
+	}
+	return alerts
+}
+
+// index returns the position of the geofence with the given ID, or -1. The
+// caller must hold the lock.
+func (s *Store) index(id string) int {
+	return slices.IndexFunc(s.geofences, func(g Geofence) bool { return g.ID == id })
+}
+
+// save writes the geofences to their file, replacing it atomically. The caller
+// must hold the write lock.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.geofences, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".geofences-*")
+	if err != nil {
+		return fmt.Errorf("failed to save geofences: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save geofences: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save geofences: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save geofences: %w", err)
+	}
+	return nil
+}
+
+// newID returns a random 16-character hex identifier.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,199 @@
+// Package health answers liveness and readiness probes. Liveness only shows that
+// the process is serving requests; readiness runs a check for each component the
+// server depends on and reports each one, and fails once shutdown has begun so
+// that load balancers stop routing new requests to the server. Component results
+// are reused for a short while, so frequent probes do not repeat expensive checks.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShuttingDown is reported by readiness once the server has begun to shut down.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Status values reported for the server and each component.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check reports whether a component is working, returning nil if it is. It
+// should give up when ctx is done.
+type Check func(ctx context.Context) error
+
+// Checker holds the components checked for readiness.
+type Checker struct {
+	timeout  time.Duration
+	maxAge   time.Duration
+	mu       sync.Mutex
+	checks   []component
+	shutdown atomic.Bool
+
+	runMu   sync.Mutex // held while the components are checked
+	results []Result   // of the last check, nil if there has been none
+	checked time.Time
+}
+
+// component is a named readiness check.
+type component struct {
+	name  string
+	check Check
+}
+
+// Result is the outcome of checking one component.
+type Result struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_ms"`
+}
+
+// Report is the body of a readiness response.
+type Report struct {
+	Status     string   `json:"status"`
+	Components []Result `json:"components"`
+}
+
+// NewChecker creates a checker that allows each check up to timeout and reuses
+// the results for up to maxAge.
+func NewChecker(timeout, maxAge time.Duration) *Checker {
+	return &Checker{timeout: timeout, maxAge: maxAge}
+}
+
+// Add registers a component to check for readiness. Components are reported in
+// the order they were added.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, component{name, check})
+	c.results = nil
+}
+
+// Shutdown marks the server as shutting down, so readiness fails from now on.
+func (c *Checker) Shutdown() {
+	c.shutdown.Store(true)
+}
+
+// Run returns the combined report of every component. The server is ready only
+// if every component is and shutdown has not begun. Shutdown is checked on every
+// call; the other components are checked again only once their last results are
+// older than the checker's maximum age.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := append([]Result{c.run(ctx, component{"server", c.checkServer})}, c.components(ctx)...)
+
+	report := Report{Status: StatusOK, Components: results}
+	for _, r := range results {
+		if r.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// HandleHealthz answers liveness probes. It succeeds whenever the server can
+// respond at all, including during shutdown.
+func (c *Checker) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// HandleReadyz answers readiness probes with a report for each component,
+// returning 503 Service Unavailable if any check fails.
+func (c *Checker) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := c.Run(r.Context())
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, report)
+}
+
+// components returns the results of the registered components, checking them
+// concurrently unless the last results are recent enough. Concurrent callers
+// share a single check, which is not cut short when one of them goes away.
+func (c *Checker) components(ctx context.Context) []Result {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	c.mu.Lock()
+	checks := slices.Clone(c.checks)
+	if c.results != nil && time.Since(c.checked) < c.maxAge {
+		results := slices.Clone(c.results)
+		c.mu.Unlock()
+		return results
+	}
+	c.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, comp := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, comp)
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	c.results, c.checked = results, time.Now()
+	c.mu.Unlock()
+	return slices.Clone(results)
+}
+
+// run checks one component within the checker's timeout.
+func (c *Checker) run(ctx context.Context, comp component) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := comp.check(ctx)
+	result := Result{
+		Name:     comp.name,
+		Status:   StatusOK,
+		Duration: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkServer fails once shutdown has begun.
+func (c *Checker) checkServer(context.Context) error {
+	if c.shutdown.Load() {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// writeJSON writes v as an uncached JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package https supports serving the site over TLS: it creates self-signed
+// certificates for local development and redirects plain HTTP requests to the
+// HTTPS listener.
+package https
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Development certificate files, created inside the directory given to DevCertificate.
+const (
+	devCertFile = "cert.pem"
+	devKeyFile  = "key.pem"
+)
+
+// devValidity is how long a generated development certificate lasts.
+const devValidity = 365 * 24 * time.Hour
+
+// devHosts are the names a development certificate is valid for.
+var devHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// DevCertificate returns the certificate and key files of a self-signed
+// certificate for localhost in dir, generating them on first use and again once
+// they are unreadable or about to expire. Browsers will warn about it, since no
+// authority vouches for it; it is meant for development only.
+func DevCertificate(dir string) (certFile, keyFile string, err error) {
+	certFile, keyFile = filepath.Join(dir, devCertFile), filepath.Join(dir, devKeyFile)
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	switch {
+	case err == nil && time.Until(pair.Leaf.NotAfter) > 24*time.Hour:
+		return certFile, keyFile, nil
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		log.Printf("Replacing development certificate in %s: %v", dir, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", "", fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	if err := writeDevCertificate(certFile, keyFile); err != nil {
+		return "", "", err
+	}
+	log.Printf("Generated self-signed development certificate %s", certFile)
+	return certFile, keyFile, nil
+}
+
+// writeDevCertificate generates a self-signed ECDSA certificate for devHosts and
+// writes it and its private key as PEM.
+func writeDevCertificate(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Creature Sighting development"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(devValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range devHosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER, 0o600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0o644)
+}
+
+// writePEM writes one PEM block to path with the given permissions.
+func writePEM(path, blockType string, der []byte, perm fs.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Redirect returns a handler that permanently redirects every request to the same
+// host and path over HTTPS on the port of httpsAddr, such as ":8443" or ":443".
+func Redirect(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		if host == "" {
+			http.Error(w, "Host header required", http.StatusBadRequest)
+			return
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTP counts requests and times them for each route.
+type HTTP struct {
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+// NewHTTP registers the request metrics with r.
+func NewHTTP(r *Registry) *HTTP {
+	return &HTTP{
+		requests: r.Counter("creature_http_requests_total", "HTTP requests handled, by route, method and status code.", "route", "method", "code"),
+		duration: r.Histogram("creature_http_request_duration_seconds", "Time taken to handle HTTP requests, by route.", DefaultBuckets, "route"),
+	}
+}
+
+// Instrument wraps the handler registered for a route, recording each request
+// under the route's pattern rather than its path so that IDs in paths do not
+// create a series per resource. Requests that panic are counted as 500s.
+func (m *HTTP) Instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		completed := false
+		defer func() {
+			// A handler that panics before responding will be answered with a 500
+			code := rec.code
+			if !completed && !rec.wroteHeader {
+				code = http.StatusInternalServerError
+			}
+			m.requests.Inc(route, r.Method, strconv.Itoa(code))
+			m.duration.Observe(time.Since(start).Seconds(), route)
+		}()
+		next.ServeHTTP(rec, r)
+		completed = true
+	})
+}
+
+// statusRecorder notes the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+// WriteHeader records the first status code sent.
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code, r.wroteHeader = code, true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write marks the header as sent, since writing a body implies a status.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer, so http.ResponseController can reach it.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,298 @@
+// Package metrics records counters and histograms and serves them in the
+// Prometheus text exposition format, so the server can be scraped without any
+// client library. Values that already live elsewhere, such as storage sizes, are
+// exported through functions called at scrape time instead of being mirrored.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, used for latency histograms.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is one value of a function-backed metric, with label values in the
+// order the metric declared its labels.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Registry holds every metric the server exports, in registration order.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// metric is a family of series sharing a name, help text and type.
+type metric interface {
+	// write appends the metric's samples in exposition format.
+	write(w io.Writer)
+}
+
+// header describes a metric family.
+type header struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		header: header{name, help, "counter", labels},
+		values: make(map[string]*counterValue),
+	}
+	r.register(name, c)
+	return c
+}
+
+// Histogram registers a histogram with the given bucket upper bounds, which must
+// be in increasing order, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		header:  header{name, help, "histogram", labels},
+		buckets: slices.Clone(buckets),
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(name, h)
+	return h
+}
+
+// CounterFunc registers a counter whose samples are read from collect at scrape
+// time. collect must return values that only ever increase.
+func (r *Registry) CounterFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(name, &funcMetric{header{name, help, "counter", labels}, collect})
+}
+
+// GaugeFunc registers a gauge whose samples are read from collect at scrape time.
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(name, &funcMetric{header{name, help, "gauge", labels}, collect})
+}
+
+// register adds m under name. Registering a name twice is a programming error.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// ServeHTTP writes every metric in the Prometheus text exposition format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(out)
+	}
+	out.Flush()
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	header
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+// counterValue is one series of a counter.
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// Inc adds one to the series with the given label values.
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add adds v, which must not be negative, to the series with the given label values.
+func (c *CounterVec) Add(v float64, labels ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := seriesKey(c.labels, labels)
+	s, ok := c.values[key]
+	if !ok {
+		s = &counterValue{labels: slices.Clone(labels)}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+// write appends the counter's series in label order.
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, s := range c.values {
+		samples = append(samples, Sample{s.labels, s.value})
+	}
+	c.mu.Unlock()
+
+	c.writeHeader(w)
+	sortSamples(samples)
+	for _, s := range samples {
+		writeSample(w, c.name, c.labels, s.Labels, "", "", s.Value)
+	}
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	header
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+// histogramValue is one series of a histogram. counts holds the observations in
+// each bucket alone; they are accumulated when written.
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records v in the series with the given label values.
+func (h *HistogramVec) Observe(v float64, labels ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey(h.labels, labels)
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramValue{labels: slices.Clone(labels), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = s
+	}
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// write appends each series' cumulative buckets, sum and count in label order.
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	series := make([]histogramValue, 0, len(h.values))
+	for _, s := range h.values {
+		series = append(series, histogramValue{s.labels, slices.Clone(s.counts), s.count, s.sum})
+	}
+	h.mu.Unlock()
+
+	h.writeHeader(w)
+	slices.SortFunc(series, func(a, b histogramValue) int { return slices.Compare(a.labels, b.labels) })
+	for _, s := range series {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labels, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+// funcMetric is a counter or gauge read from a function at scrape time.
+type funcMetric struct {
+	header
+	collect func() []Sample
+}
+
+// write appends the samples returned by the metric's function in label order.
+func (f *funcMetric) write(w io.Writer) {
+	samples := f.collect()
+	f.writeHeader(w)
+	sortSamples(samples)
+	for _, s := range samples {
+		writeSample(w, f.name, f.labels, s.Labels, "", "", s.Value)
+	}
+}
+
+// writeHeader appends the HELP and TYPE lines of a metric family.
+func (h *header) writeHeader(w io.Writer) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(h.help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", h.name, help, h.name, h.kind)
+}
+
+// writeSample appends one sample line. An extra label, such as a histogram's
+// "le", is added after the declared ones when extraName is set.
+func writeSample(w io.Writer, name string, names, values []string, extraName, extraValue string, v float64) {
+	var pairs []string
+	for i, n := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, n+`="`+escapeLabel(value)+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+
+	if len(pairs) > 0 {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, strings.Join(pairs, ","), formatFloat(v))
+	} else {
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+	}
+}
+
+// seriesKey identifies the series for a set of label values, panicking if the
+// number of values does not match the metric's labels.
+func seriesKey(names, values []string) string {
+	if len(names) != len(values) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(values), names))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sortSamples orders samples by their label values so output is stable.
+func sortSamples(samples []Sample) {
+	slices.SortFunc(samples, func(a, b Sample) int { return slices.Compare(a.Labels, b.Labels) })
+}
+
+// escapeLabel escapes a label value for the exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats a sample value, spelling infinities the way Prometheus does.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,266 @@
+// Package names generates novel, pronounceable creature names.
+// Each category has an embedded training corpus from which a character-level Markov
+// chain learns which letters tend to follow each other. A Namer draws names from the
+// chain and guarantees they do not collide with names already in use.
+//
+// Only categories whose creatures are individuals take names from here. Cryptid
+// sightings name the folklore creature seen, which recurs by design, and UFO
+// sightings carry a designation built from the object's shape and heading.
+package names
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+)
+
+//go:embed corpus/*.txt
+var corpora embed.FS
+
+// Generation limits for drawn names.
+const (
+	order       = 2  // letters of context used to predict the next letter
+	minLength   = 4  // shortest acceptable name
+	maxLength   = 12 // longest acceptable name
+	maxAttempts = 200
+)
+
+// Chain boundary markers; neither appears in any corpus.
+const (
+	startMark = '^'
+	endMark   = '$'
+)
+
+// reservation is how long a name drawn from an unseeded source stays reserved,
+// covering the time between generating a sighting and storing it.
+const reservation = time.Minute
+
+// suffixes disambiguate a name once the chain can no longer produce a free one.
+var suffixes = []string{"II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X"}
+
+// Model is a character-level Markov chain trained on a corpus of names.
+type Model struct {
+	transitions map[string][]rune // each follower appears once per observation, so picks are weighted
+	corpus      map[string]bool   // lower-cased training names, never emitted
+}
+
+// Train builds a model from a list of example names.
+func Train(examples []string) *Model {
+	m := &Model{
+		transitions: make(map[string][]rune),
+		corpus:      make(map[string]bool),
+	}
+
+	for _, example := range examples {
+		word := strings.ToLower(strings.TrimSpace(example))
+		if word == "" {
+			continue
+		}
+		m.corpus[word] = true
+
+		padded := []rune(strings.Repeat(string(startMark), order) + word + string(endMark))
+		for i := order; i < len(padded); i++ {
+			context := string(padded[i-order : i])
+			m.transitions[context] = append(m.transitions[context], padded[i])
+		}
+	}
+
+	return m
+}
+
+// Load trains a model on the embedded corpus for category.
+func Load(category string) (*Model, error) {
+	f, err := corpora.Open("corpus/" + category + ".txt")
+	if err != nil {
+		return nil, fmt.Errorf("no name corpus for category %s: %w", category, err)
+	}
+	defer f.Close()
+
+	var examples []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		examples = append(examples, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read name corpus for category %s: %w", category, err)
+	}
+
+	return Train(examples), nil
+}
+
+// Generate draws a novel, pronounceable name from the chain. Names copied verbatim
+// from the corpus, names outside the length limits, and names with awkward runs of
+// three consonants or three vowels are rejected and redrawn.
+func (m *Model) Generate(rng *random.Source) (string, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		word, err := m.walk(rng)
+		if err != nil {
+			return "", err
+		}
+		if len([]rune(word)) < minLength || m.corpus[word] || !pronounceable(word) {
+			continue
+		}
+		return capitalize(word), nil
+	}
+	return "", fmt.Errorf("no acceptable name after %d attempts", maxAttempts)
+}
+
+// walk follows the chain from the start marker until it reaches the end marker
+// or the maximum length.
+func (m *Model) walk(rng *random.Source) (string, error) {
+	var b strings.Builder
+	context := strings.Repeat(string(startMark), order)
+
+	for b.Len() <= maxLength {
+		followers := m.transitions[context]
+		if len(followers) == 0 {
+			break
+		}
+		idx, err := rng.Int(0, len(followers)-1)
+		if err != nil {
+			return "", err
+		}
+		next := followers[idx]
+		if next == endMark {
+			return b.String(), nil
+		}
+		b.WriteRune(next)
+		context = string([]rune(context)[1:]) + string(next)
+	}
+
+	// Ran past the maximum length without ending; an empty word is always rejected
+	return "", nil
+}
+
+// pronounceable reports whether word avoids runs of three consonants or three vowels.
+func pronounceable(word string) bool {
+	run, vowelRun := 0, false
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			run = 0
+			continue
+		}
+		vowel := strings.ContainsRune("aeiouy", r)
+		if run > 0 && vowel == vowelRun {
+			run++
+		} else {
+			run, vowelRun = 1, vowel
+		}
+		if run >= 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// capitalize upper-cases the first letter of word.
+func capitalize(word string) string {
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// Namer issues names for a category that are not already taken. Names reported as
+// taken by the optional check, typically a storage lookup, are skipped, and so are
+// names the namer has handed out to unseeded draws within the last minute, so two
+// sightings generated at once never share a name. Seeded draws skip reserved names
+// but reserve none, so the same seed reproduces the same name until that name is
+// stored.
+type Namer struct {
+	model *Model
+
+	mu       sync.Mutex
+	taken    func(name string) bool
+	reserved map[string]time.Time // lower-cased name to when its reservation ends
+}
+
+// NewNamer creates a namer backed by the embedded corpus for category.
+func NewNamer(category string) (*Namer, error) {
+	model, err := Load(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Namer{model: model, reserved: make(map[string]time.Time)}, nil
+}
+
+// MustNewNamer is like NewNamer but panics if the category has no embedded corpus.
+// It is intended for generators whose corpus ships with this package.
+func MustNewNamer(category string) *Namer {
+	n, err := NewNamer(category)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// SetTaken installs a check reporting names already in use elsewhere, such as in storage.
+func (n *Namer) SetTaken(taken func(name string) bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.taken = taken
+}
+
+// Name returns a name that is neither taken nor reserved, reserving it if rng is
+// unseeded. If the chain cannot find a free name, the last candidate is
+// disambiguated with a regnal suffix.
+func (n *Namer) Name(rng *random.Source) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	for name, until := range n.reserved {
+		if now.After(until) {
+			delete(n.reserved, name)
+		}
+	}
+
+	var last string
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		name, err := n.model.Generate(rng)
+		if err != nil {
+			return "", err
+		}
+		last = name
+		if n.available(name) {
+			return n.reserve(name, rng, now), nil
+		}
+	}
+
+	for _, suffix := range suffixes {
+		name := last + " " + suffix
+		if n.available(name) {
+			return n.reserve(name, rng, now), nil
+		}
+	}
+
+	return "", fmt.Errorf("no unique name available")
+}
+
+// available reports whether name is free. Callers hold n.mu.
+func (n *Namer) available(name string) bool {
+	if _, ok := n.reserved[strings.ToLower(name)]; ok {
+		return false
+	}
+	return n.taken == nil || !n.taken(name)
+}
+
+// reserve holds name for the reservation period if rng is unseeded, and returns
+// it. Callers hold n.mu.
+func (n *Namer) reserve(name string, rng *random.Source, now time.Time) string {
+	if !rng.Seeded() {
+		n.reserved[strings.ToLower(name)] = now.Add(reservation)
+	}
+	return name
+}
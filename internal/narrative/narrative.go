@@ -0,0 +1,272 @@
+// Package narrative writes field reports from weighted template grammars.
+// A grammar maps symbol names to weighted alternatives, in the style of Tracery:
+// "#symbol#" in an alternative expands to one of that symbol's alternatives, chosen
+// with a random source so that a seeded source always yields the same text.
+// Each category keeps its grammar in grammars/<category>.json.
+package narrative
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pymk/creature-sighting/internal/creatures/random"
+)
+
+//go:embed grammars/*.json
+var grammars embed.FS
+
+// Start is the symbol expanded to produce a complete report.
+const Start = "origin"
+
+// maxDepth bounds recursive expansion so a cyclic grammar fails instead of hanging.
+const maxDepth = 32
+
+// Grammar is a set of rules mapping symbol names to weighted alternatives.
+type Grammar struct {
+	rules map[string][]Alternative
+}
+
+// Alternative is one possible expansion of a symbol. In JSON an alternative is
+// either a plain string with weight 1 or an object with "text" and "weight".
+type Alternative struct {
+	Text   string `json:"text"`
+	Weight int    `json:"weight"`
+}
+
+// UnmarshalJSON accepts either a bare string or a {"text", "weight"} object.
+func (a *Alternative) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*a = Alternative{Text: text, Weight: 1}
+		return nil
+	}
+
+	type plain Alternative
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if p.Weight <= 0 {
+		p.Weight = 1
+	}
+	*a = Alternative(p)
+	return nil
+}
+
+// Vars supplies per-report values such as the city or an attribute. Each variable
+// behaves like a rule whose alternatives are equally weighted and inserted verbatim,
+// and takes precedence over a grammar rule of the same name.
+type Vars map[string][]string
+
+// Set assigns one or more values to a variable.
+func (v Vars) Set(name string, values ...string) {
+	v[name] = values
+}
+
+// Parse builds a grammar from its JSON form: an object mapping each symbol to an
+// array of alternatives.
+func Parse(data []byte) (*Grammar, error) {
+	var rules map[string][]Alternative
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse grammar: %w", err)
+	}
+	if _, ok := rules[Start]; !ok {
+		return nil, fmt.Errorf("parse grammar: missing %q rule", Start)
+	}
+	return &Grammar{rules: rules}, nil
+}
+
+// Load parses the embedded grammar for category.
+func Load(category string) (*Grammar, error) {
+	data, err := grammars.ReadFile("grammars/" + category + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("no grammar for category %s: %w", category, err)
+	}
+	return Parse(data)
+}
+
+// MustLoad is like Load but panics if the grammar is missing or malformed.
+// It is intended for generators whose grammar ships with this package.
+func MustLoad(category string) *Grammar {
+	g, err := Load(category)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Generate expands the start symbol into a complete report.
+func (g *Grammar) Generate(rng *random.Source, vars Vars) (string, error) {
+	return g.Expand(rng, Start, vars)
+}
+
+// Expand expands a single symbol, for grammars that build reports from parts.
+func (g *Grammar) Expand(rng *random.Source, symbol string, vars Vars) (string, error) {
+	text, err := g.expand(rng, vars, "#"+symbol+"#", 0)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(text), " "), nil
+}
+
+// expand replaces every "#tag#" in text with an expansion of the tag. A tag is a
+// symbol name optionally followed by modifiers, as in "#size.a#". Inside a tag,
+// "{name}" is replaced by the value of variable name first, so "#proof_{evidence}#"
+// selects a rule by an attribute value.
+func (g *Grammar) expand(rng *random.Source, vars Vars, text string, depth int) (string, error) {
+	if depth > maxDepth {
+		return "", fmt.Errorf("grammar expansion exceeded depth %d", maxDepth)
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(text, '#')
+		if start < 0 {
+			b.WriteString(text)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(text[start+1:], '#')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated tag in %q", text)
+		}
+		end += start + 1
+
+		b.WriteString(text[:start])
+		expanded, err := g.expandTag(rng, vars, text[start+1:end], depth)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expanded)
+		text = text[end+1:]
+	}
+}
+
+// expandTag resolves a single tag to text and applies its modifiers.
+func (g *Grammar) expandTag(rng *random.Source, vars Vars, tag string, depth int) (string, error) {
+	tag, err := interpolate(tag, vars)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(tag, ".")
+	symbol, modifiers := parts[0], parts[1:]
+
+	var text string
+	if values, ok := vars[symbol]; ok {
+		// Variables hold report data such as place names, which are never expanded
+		text, err = choose(rng, literal(values))
+		if err != nil {
+			return "", fmt.Errorf("expand %s: %w", symbol, err)
+		}
+	} else {
+		alternatives, ok := g.rules[symbol]
+		if !ok {
+			return "", fmt.Errorf("unknown grammar symbol %q", symbol)
+		}
+		choice, err := choose(rng, alternatives)
+		if err != nil {
+			return "", fmt.Errorf("expand %s: %w", symbol, err)
+		}
+		text, err = g.expand(rng, vars, choice, depth+1)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, modifier := range modifiers {
+		text, err = modify(text, modifier)
+		if err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// literal wraps variable values as equally weighted alternatives.
+func literal(values []string) []Alternative {
+	alternatives := make([]Alternative, 0, len(values))
+	for _, v := range values {
+		alternatives = append(alternatives, Alternative{Text: v, Weight: 1})
+	}
+	return alternatives
+}
+
+// interpolate replaces "{name}" references in a tag with the first value of each variable.
+func interpolate(tag string, vars Vars) (string, error) {
+	for {
+		start := strings.IndexByte(tag, '{')
+		if start < 0 {
+			return tag, nil
+		}
+		end := strings.IndexByte(tag[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable in tag %q", tag)
+		}
+		end += start
+
+		name := tag[start+1 : end]
+		values := vars[name]
+		if len(values) == 0 {
+			return "", fmt.Errorf("unknown variable %q in tag", name)
+		}
+		tag = tag[:start] + values[0] + tag[end+1:]
+	}
+}
+
+// choose picks an alternative with probability proportional to its weight.
+func choose(rng *random.Source, alternatives []Alternative) (string, error) {
+	total := 0
+	for _, a := range alternatives {
+		total += max(a.Weight, 1)
+	}
+	if total == 0 {
+		return "", fmt.Errorf("no alternatives")
+	}
+
+	pick, err := rng.Int(1, total)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range alternatives {
+		pick -= max(a.Weight, 1)
+		if pick <= 0 {
+			return a.Text, nil
+		}
+	}
+	return alternatives[len(alternatives)-1].Text, nil
+}
+
+// modify applies a named modifier to expanded text.
+func modify(text, modifier string) (string, error) {
+	switch modifier {
+	case "capitalize":
+		return capitalize(text), nil
+	case "a":
+		return article(text) + " " + text, nil
+	case "lower":
+		return strings.ToLower(text), nil
+	case "upper":
+		return strings.ToUpper(text), nil
+	default:
+		return "", fmt.Errorf("unknown grammar modifier %q", modifier)
+	}
+}
+
+// capitalize upper-cases the first letter of text.
+func capitalize(text string) string {
+	for i, r := range text {
+		return text[:i] + string(unicode.ToUpper(r)) + text[i+len(string(r)):]
+	}
+	return text
+}
+
+// article returns "an" for words starting with a vowel and "a" otherwise.
+func article(text string) string {
+	if text != "" && strings.ContainsRune("aeiouAEIOU", rune(text[0])) {
+		return "an"
+	}
+	return "a"
+}
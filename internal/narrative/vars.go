@@ -0,0 +1,111 @@
+package narrative
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/geo"
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/weather"
+)
+
+// TimeOfDay names the part of the day for a local solar hour in [0, 24).
+func TimeOfDay(hour float64) string {
+	switch {
+	case hour < 5:
+		return "night"
+	case hour < 7:
+		return "dawn"
+	case hour < 11:
+		return "morning"
+	case hour < 14:
+		return "midday"
+	case hour < 18:
+		return "afternoon"
+	case hour < 21:
+		return "dusk"
+	default:
+		return "night"
+	}
+}
+
+// timeOfDay names the part of the day, correcting the clock-based name with the
+// daylight in w when known, so that a London evening in winter counts as night.
+func timeOfDay(hour float64, w *weather.Conditions) string {
+	name := TimeOfDay(hour)
+	if w == nil {
+		return name
+	}
+
+	switch w.Daylight {
+	case weather.Night:
+		return "night"
+	case weather.Twilight:
+		if hour < 12 {
+			return "dawn"
+		}
+		return "dusk"
+	}
+
+	// The sun is up: early and late hours in summer are still day
+	switch name {
+	case "dawn":
+		return "morning"
+	case "dusk":
+		return "afternoon"
+	}
+	return name
+}
+
+// SightingVars returns the variables every grammar may refer to for s: name, type,
+// category, place (the city, or coordinates when there is none), city, country,
+// region, time_of_day, clock (local solar time as "15:04"), weekday, month, and one
+// variable per attribute formatted with its unit from schema. When s carries weather,
+// temperature, precipitation, sky, visibility, daylight, moon_phase and moon (the
+// phase as a noun, as in "waxing gibbous moon") are set too, and time_of_day follows
+// the sun rather than the clock.
+func SightingVars(s *sighting.Sighting, schema sighting.Schema) Vars {
+	hour := geo.LocalSolarHour(s.Timestamp, s.Location.Longitude)
+
+	place := s.Location.City
+	if place == "" {
+		place = fmt.Sprintf("%.2f, %.2f", s.Location.Latitude, s.Location.Longitude)
+	}
+
+	vars := Vars{}
+	vars.Set("name", s.Name)
+	vars.Set("type", s.Type)
+	vars.Set("category", s.Category)
+	vars.Set("place", place)
+	vars.Set("city", s.Location.City)
+	vars.Set("country", s.Location.Country)
+	vars.Set("region", s.Location.Region)
+	vars.Set("time_of_day", timeOfDay(hour, s.Weather))
+	vars.Set("clock", fmt.Sprintf("%02d:%02d", int(hour), int(hour*60)%60))
+	vars.Set("weekday", s.Timestamp.Weekday().String())
+	vars.Set("month", s.Timestamp.Month().String())
+
+	for name, value := range s.Attributes {
+		if field, ok := schema.Field(name); ok {
+			vars.Set(name, field.Format(value))
+		} else {
+			vars.Set(name, fmt.Sprintf("%v", value))
+		}
+	}
+
+	if w := s.Weather; w != nil {
+		vars.Set("temperature", fmt.Sprintf("%.0f°C", w.Temperature))
+		vars.Set("precipitation", w.Precipitation)
+		vars.Set("sky", w.Sky)
+		vars.Set("visibility", fmt.Sprintf("%g km", w.Visibility))
+		vars.Set("daylight", w.Daylight)
+		vars.Set("moon_phase", w.MoonPhase)
+		if strings.HasSuffix(w.MoonPhase, "moon") {
+			vars.Set("moon", w.MoonPhase)
+		} else {
+			vars.Set("moon", w.MoonPhase+" moon")
+		}
+	}
+
+	return vars
+}
@@ -0,0 +1,377 @@
+// Package plugin runs creature generators as external executables.
+// Each plugin is a long-lived child process speaking newline-delimited JSON over
+// stdin and stdout: one Request line in, one Response line out. A plugin is
+// described once at start-up, supervised while the server runs, and restarted
+// with backoff whenever it exits or stops answering in time.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// ErrUnavailable is returned when a plugin process is not running, for example
+// while it is being restarted after a crash.
+var ErrUnavailable = errors.New("plugin unavailable")
+
+// Protocol methods understood by plugins.
+const (
+	MethodDescribe = "describe"
+	MethodGenerate = "generate"
+)
+
+// Backoff bounds for restarting a crashed plugin. The delay doubles after each
+// quick crash and resets once a process has stayed up for stableAfter.
+const (
+	minBackoff  = time.Second
+	maxBackoff  = 30 * time.Second
+	stableAfter = time.Minute
+)
+
+// Request is written to a plugin's stdin as a single JSON line.
+type Request struct {
+	Method  string            `json:"method"`
+	Options *sighting.Options `json:"options,omitempty"`
+}
+
+// Response is read from a plugin's stdout as a single JSON line. A describe
+// request is answered with Category, a generate request with Sighting; either
+// may instead carry Error.
+type Response struct {
+	Category *sighting.CategoryInfo `json:"category,omitempty"`
+	Sighting *sighting.Sighting     `json:"sighting,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// Plugin is a sighting generator backed by an external executable. It implements
+// sighting.Generator, sighting.ContextGenerator and sighting.Describer.
+type Plugin struct {
+	path    string
+	timeout time.Duration
+	info    sighting.CategoryInfo
+
+	mu     sync.Mutex // serialises requests and guards proc and closed
+	proc   *process
+	closed bool
+}
+
+// process is a single running instance of a plugin executable.
+type process struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	started time.Time
+	done    chan struct{} // closed when the process exits
+	idle    chan struct{} // closed once the last request has finished with stdout
+}
+
+// Start launches the executable at path, asks it to describe its category, and
+// begins supervising it. Requests that take longer than timeout kill the process,
+// even if the caller has given up on them.
+func Start(path string, timeout time.Duration) (*Plugin, error) {
+	p := &Plugin{
+		path:    path,
+		timeout: timeout,
+	}
+
+	proc, err := p.spawn()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.roundTrip(context.Background(), proc, Request{Method: MethodDescribe})
+	if err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("describe plugin %s: %w", path, err)
+	}
+	if resp.Category == nil || resp.Category.Name == "" {
+		proc.kill()
+		return nil, fmt.Errorf("describe plugin %s: no category declared", path)
+	}
+
+	p.info = *resp.Category
+	p.proc = proc
+	go p.supervise(proc)
+
+	return p, nil
+}
+
+// Discover starts every executable file directly inside dir as a plugin.
+// Plugins that fail to start are logged and skipped.
+func Discover(dir string, timeout time.Duration) ([]*Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read plugin directory: %w", err)
+	}
+
+	plugins := make([]*Plugin, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		p, err := Start(path, timeout)
+		if err != nil {
+			log.Printf("Skipping plugin %s: %v", path, err)
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+
+	return plugins, nil
+}
+
+// Category returns the category declared by the plugin.
+func (p *Plugin) Category() string {
+	return p.info.Name
+}
+
+// Describe returns the category metadata declared by the plugin.
+func (p *Plugin) Describe() sighting.CategoryInfo {
+	return p.info
+}
+
+// Generate asks the plugin for a sighting with no options.
+func (p *Plugin) Generate() (*sighting.Sighting, error) {
+	return p.GenerateWithOptions(context.Background(), sighting.Options{})
+}
+
+// GenerateWithOptions asks the plugin for a sighting honouring opts. The plugin's
+// category is enforced and missing IDs and timestamps are filled in.
+func (p *Plugin) GenerateWithOptions(ctx context.Context, opts sighting.Options) (*sighting.Sighting, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || p.proc == nil || p.proc.exited() {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, p.info.Name)
+	}
+
+	resp, err := p.roundTrip(ctx, p.proc, Request{Method: MethodGenerate, Options: &opts})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Sighting == nil {
+		return nil, fmt.Errorf("plugin %s returned no sighting", p.info.Name)
+	}
+
+	s := resp.Sighting
+	s.Category = p.info.Name
+	if s.ID == "" {
+		s.ID = fmt.Sprintf("%s-%d", p.info.Name, time.Now().UnixNano())
+	}
+	if s.Timestamp.IsZero() {
+		s.Timestamp = time.Now()
+	}
+
+	return s, nil
+}
+
+// Check reports whether the plugin's process is running, returning an error
+// wrapping ErrUnavailable while it is being restarted or after it is closed.
+func (p *Plugin) Check() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || p.proc == nil || p.proc.exited() {
+		return fmt.Errorf("%w: %s", ErrUnavailable, p.info.Name)
+	}
+	return nil
+}
+
+// Close stops supervising the plugin and terminates its process.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.proc != nil {
+		p.proc.kill()
+	}
+	return nil
+}
+
+// roundTrip sends req to proc and waits for its response. If the plugin does not
+// answer within the timeout the process is killed, so that its output cannot be
+// mistaken for the answer to a later request. A caller whose ctx is cancelled
+// stops waiting, but the request carries on under its own timeout so that one
+// impatient client does not restart the plugin for everyone; the next request
+// waits for its response to be read first. The caller must hold p.mu or own proc
+// exclusively.
+func (p *Plugin) roundTrip(ctx context.Context, proc *process, req Request) (*Response, error) {
+	line, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode plugin request: %w", err)
+	}
+
+	// Wait for a request abandoned by its caller to finish with stdout
+	select {
+	case <-proc.idle:
+	case <-proc.done:
+		return nil, fmt.Errorf("%w: %s exited", ErrUnavailable, p.path)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+	results := make(chan result, 1)
+	idle := make(chan struct{})
+	proc.idle = idle
+
+	expired := make(chan struct{})
+	timer := time.AfterFunc(p.timeout, func() {
+		close(expired)
+		proc.kill()
+	})
+
+	go func() {
+		defer close(idle)
+		defer timer.Stop()
+
+		if _, err := proc.stdin.Write(append(line, '\n')); err != nil {
+			results <- result{err: fmt.Errorf("write plugin request: %w", err)}
+			return
+		}
+		data, err := proc.stdout.ReadBytes('\n')
+		if err != nil {
+			results <- result{err: fmt.Errorf("read plugin response: %w", err)}
+			return
+		}
+		var resp Response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			results <- result{err: fmt.Errorf("decode plugin response: %w", err)}
+			return
+		}
+		if resp.Error != "" {
+			results <- result{err: fmt.Errorf("plugin error: %s", resp.Error)}
+			return
+		}
+		results <- result{resp: &resp}
+	}()
+
+	select {
+	case r := <-results:
+		return r.resp, r.err
+	case <-expired:
+		return nil, fmt.Errorf("plugin %s timed out after %s", p.path, p.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-proc.done:
+		return nil, fmt.Errorf("%w: %s exited", ErrUnavailable, p.path)
+	}
+}
+
+// spawn starts a new process for the plugin executable.
+func (p *Plugin) spawn() (*process, error) {
+	cmd := exec.Command(p.path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start plugin %s: %w", p.path, err)
+	}
+
+	proc := &process{
+		cmd:     cmd,
+		stdin:   stdin,
+		stdout:  bufio.NewReader(stdout),
+		started: time.Now(),
+		done:    make(chan struct{}),
+		idle:    closedChan(),
+	}
+	go func() {
+		_ = cmd.Wait()
+		close(proc.done)
+	}()
+
+	return proc, nil
+}
+
+// supervise waits for proc to exit and replaces it with a fresh process, backing
+// off between attempts, until the plugin is closed.
+func (p *Plugin) supervise(proc *process) {
+	backoff := minBackoff
+	for {
+		<-proc.done
+
+		p.mu.Lock()
+		closed := p.closed
+		p.mu.Unlock()
+		if closed {
+			return
+		}
+
+		if time.Since(proc.started) > stableAfter {
+			backoff = minBackoff
+		}
+		log.Printf("Plugin %s (%s) exited; restarting in %s", p.info.Name, p.path, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+
+		next, err := p.spawn()
+		if err != nil {
+			log.Printf("Plugin %s restart failed: %v", p.info.Name, err)
+			// Retry on the next loop iteration using an already-exited process
+			proc = &process{started: time.Now(), done: closedChan()}
+			continue
+		}
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			next.kill()
+			return
+		}
+		p.proc = next
+		p.mu.Unlock()
+
+		proc = next
+	}
+}
+
+// exited reports whether the process has terminated.
+func (proc *process) exited() bool {
+	select {
+	case <-proc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// kill terminates the process if it is still running.
+func (proc *process) kill() {
+	if proc.cmd != nil && proc.cmd.Process != nil && !proc.exited() {
+		_ = proc.cmd.Process.Kill()
+	}
+}
+
+// closedChan returns an already-closed channel.
+func closedChan() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
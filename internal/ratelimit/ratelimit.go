@@ -0,0 +1,198 @@
+// Package ratelimit throttles API clients. Each client has a token bucket that
+// allows short bursts while capping the sustained request rate, and a quota of
+// requests per UTC day. Every request is counted against its IP address before it
+// is authenticated, so guessing tokens or passwords is throttled too; requests
+// with a valid API token are then also counted against the token, so every key
+// gets its own allowance. Counters live in memory and reset when the server
+// restarts.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/auth"
+)
+
+// sweepInterval is how often idle clients are forgotten.
+const sweepInterval = time.Minute
+
+// Config sets the allowance for each client.
+type Config struct {
+	Rate           float64 // sustained requests per second
+	Burst          int     // requests allowed at once
+	DailyQuota     int     // requests per day for each API token, unless the token sets its own
+	AnonymousQuota int     // requests per day for each IP address without a token
+}
+
+// Limiter tracks the allowance of every client it has seen.
+type Limiter struct {
+	mu        sync.Mutex
+	config    Config
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+// client is one caller's bucket and the day's request count.
+type client struct {
+	tokens float64   // requests currently available in the bucket
+	filled time.Time // when tokens was last topped up
+	day    string    // UTC date count applies to
+	count  int       // requests made on day
+}
+
+// decision is the outcome of a request and the allowance left afterwards.
+type decision struct {
+	allowed    bool
+	reason     string
+	quota      int
+	remaining  int
+	reset      time.Time     // when the daily quota renews
+	retryAfter time.Duration // how long a rejected client should wait
+}
+
+// New creates a limiter with the given allowance. Every setting must be positive.
+func New(config Config) (*Limiter, error) {
+	if config.Rate <= 0 || config.Burst < 1 || config.DailyQuota < 1 || config.AnonymousQuota < 1 {
+		return nil, fmt.Errorf("invalid rate limit %+v: every setting must be positive", config)
+	}
+	return &Limiter{
+		config:  config,
+		clients: make(map[string]*client),
+	}, nil
+}
+
+// LimitIP wraps a handler so that each IP address is held to the rate limit,
+// and requests presenting no API token to the anonymous daily quota, before the
+// request is authenticated. Requests beyond the allowance are rejected with 429
+// Too Many Requests. Every response reports the daily quota in X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset, and rejected ones say when to try
+// again in Retry-After. It must run before auth.RequireToken.
+func (l *Limiter) LimitIP(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Token holders are held to their token's quota by Limit instead
+		quota := l.config.AnonymousQuota
+		if r.Header.Get("Authorization") != "" {
+			quota = 0
+		}
+		if l.allow(w, l.take("ip:"+clientIP(r), quota)) {
+			next(w, r)
+		}
+	}
+}
+
+// Limit wraps an API handler so that requests made with an API token are held to
+// the token's own rate limit and daily quota, reporting them as LimitIP does. It
+// must run after auth.RequireToken so the request's token is known; anonymous
+// requests pass straight through, having been counted by LimitIP.
+func (l *Limiter) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := auth.TokenFrom(r.Context())
+		if !ok {
+			next(w, r)
+			return
+		}
+		quota := l.config.DailyQuota
+		if t.DailyQuota > 0 {
+			quota = t.DailyQuota
+		}
+		if l.allow(w, l.take("token:"+t.ID, quota)) {
+			next(w, r)
+		}
+	}
+}
+
+// allow writes the rate limit headers for d and, if the request was refused,
+// the 429 response. It reports whether the request may proceed.
+func (l *Limiter) allow(w http.ResponseWriter, d decision) bool {
+	h := w.Header()
+	if d.quota > 0 {
+		h.Set("X-RateLimit-Limit", strconv.Itoa(d.quota))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(d.reset.Unix(), 10))
+	}
+
+	if !d.allowed {
+		h.Set("Retry-After", strconv.Itoa(int(math.Ceil(d.retryAfter.Seconds()))))
+		http.Error(w, d.reason, http.StatusTooManyRequests)
+	}
+	return d.allowed
+}
+
+// clientIP returns the address a request came from, without its port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// take spends one request from the client's bucket and daily quota, if both have
+// room. A quota of zero leaves the daily count alone and checks only the bucket.
+func (l *Limiter) take(key string, quota int) decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{tokens: float64(l.config.Burst), filled: now}
+		l.clients[key] = c
+	}
+
+	// Top up the bucket for the time since the last request
+	c.tokens = math.Min(float64(l.config.Burst), c.tokens+now.Sub(c.filled).Seconds()*l.config.Rate)
+	c.filled = now
+
+	today := now.UTC().Format(time.DateOnly)
+	if c.day != today {
+		c.day, c.count = today, 0
+	}
+
+	d := decision{
+		quota: quota,
+		reset: now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour),
+	}
+	switch {
+	case quota > 0 && c.count >= quota:
+		d.reason = fmt.Sprintf("Daily quota of %d requests exceeded", quota)
+		d.retryAfter = d.reset.Sub(now)
+	case c.tokens < 1:
+		d.reason = fmt.Sprintf("Rate limit of %g requests per second exceeded", l.config.Rate)
+		d.retryAfter = time.Duration((1 - c.tokens) / l.config.Rate * float64(time.Second))
+	default:
+		d.allowed = true
+		c.tokens--
+		if quota > 0 {
+			c.count++
+		}
+	}
+	d.remaining = max(quota-c.count, 0)
+	return d
+}
+
+// sweep forgets clients whose buckets have refilled and who have made no
+// requests today, so that the table does not grow with every address seen. The
+// caller must hold the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	today := now.UTC().Format(time.DateOnly)
+	full := time.Duration(float64(l.config.Burst) / l.config.Rate * float64(time.Second))
+	for key, c := range l.clients {
+		if c.day != today && now.Sub(c.filled) > full {
+			delete(l.clients, key)
+		}
+	}
+}
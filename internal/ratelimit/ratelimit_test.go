@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/pymk/creature-sighting/internal/auth"
+)
+
+// ok answers every request that gets through with 200.
+func ok(w http.ResponseWriter, r *http.Request) {}
+
+// newLimiter creates a limiter with config, failing the test if it is invalid.
+func newLimiter(t *testing.T, config Config) *Limiter {
+	t.Helper()
+	l, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return l
+}
+
+// send makes a request from ip through h, with the Authorization header if given
+// and the token in its context if it has an ID, as auth.RequireToken would.
+func send(h http.HandlerFunc, ip, authorization string, token auth.Token) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/sightings", nil)
+	req.RemoteAddr = ip + ":50000"
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if token.ID != "" {
+		req = req.WithContext(auth.WithToken(req.Context(), token))
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	for _, config := range []Config{
+		{Rate: 0, Burst: 1, DailyQuota: 1, AnonymousQuota: 1},
+		{Rate: 1, Burst: 0, DailyQuota: 1, AnonymousQuota: 1},
+		{Rate: 1, Burst: 1, DailyQuota: 0, AnonymousQuota: 1},
+		{Rate: 1, Burst: 1, DailyQuota: 1, AnonymousQuota: 0},
+	} {
+		if _, err := New(config); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", config)
+		}
+	}
+}
+
+func TestLimitIPBurst(t *testing.T) {
+	l := newLimiter(t, Config{Rate: 0.001, Burst: 3, DailyQuota: 100, AnonymousQuota: 100})
+	h := l.LimitIP(ok)
+
+	for i := range 3 {
+		if rec := send(h, "192.0.2.1", "", auth.Token{}); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := send(h, "192.0.2.1", "", auth.Token{})
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request beyond the burst: status = %d, want 429", rec.Code)
+	}
+	if retry, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retry < 1 {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", rec.Header().Get("Retry-After"))
+	}
+
+	// Other addresses have buckets of their own
+	if rec := send(h, "192.0.2.2", "", auth.Token{}); rec.Code != http.StatusOK {
+		t.Errorf("another address: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestLimitIPAnonymousQuota(t *testing.T) {
+	l := newLimiter(t, Config{Rate: 1000, Burst: 1000, DailyQuota: 100, AnonymousQuota: 2})
+	h := l.LimitIP(ok)
+
+	for i, remaining := range []string{"1", "0"} {
+		rec := send(h, "192.0.2.1", "", auth.Token{})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i+1, got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %s", i+1, got, remaining)
+		}
+	}
+	if rec := send(h, "192.0.2.1", "", auth.Token{}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("request beyond the quota: status = %d, want 429", rec.Code)
+	}
+
+	// Requests with a token are left to the token's quota
+	rec := send(h, "192.0.2.1", "Bearer cs_anything", auth.Token{})
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("request with a token: status = %d and limit %q, want 200 and no limit", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestLimitIPThrottlesTokenGuesses(t *testing.T) {
+	l := newLimiter(t, Config{Rate: 0.001, Burst: 2, DailyQuota: 100, AnonymousQuota: 100})
+	h := l.LimitIP(ok)
+
+	send(h, "192.0.2.1", "Bearer cs_guess1", auth.Token{})
+	send(h, "192.0.2.1", "Bearer cs_guess2", auth.Token{})
+	if rec := send(h, "192.0.2.1", "Bearer cs_guess3", auth.Token{}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("third guess: status = %d, want 429", rec.Code)
+	}
+}
+
+func TestLimitPerToken(t *testing.T) {
+	l := newLimiter(t, Config{Rate: 1000, Burst: 1000, DailyQuota: 3, AnonymousQuota: 100})
+	h := l.Limit(ok)
+	standard := auth.Token{ID: "aaaa"}
+	own := auth.Token{ID: "bbbb", DailyQuota: 1}
+
+	for i := range 3 {
+		if rec := send(h, "192.0.2.1", "", standard); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, rec.Code)
+		}
+	}
+	if rec := send(h, "192.0.2.1", "", standard); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("request beyond the default quota: status = %d, want 429", rec.Code)
+	}
+
+	// A token's own quota replaces the default, and each token is counted apart
+	// even from the same address
+	rec := send(h, "192.0.2.1", "", own)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("token with its own quota: status = %d and limit %q, want 200 and 1", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+	if rec := send(h, "192.0.2.1", "", own); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("request beyond the token's quota: status = %d, want 429", rec.Code)
+	}
+
+	// Anonymous requests were counted by LimitIP and pass straight through
+	for range 5 {
+		if rec := send(h, "192.0.2.1", "", auth.Token{}); rec.Code != http.StatusOK {
+			t.Fatalf("anonymous request: status = %d, want 200", rec.Code)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package sighting
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInvalidType is returned for creature types their category does not declare.
+var ErrInvalidType = errors.New("invalid creature type")
+
+// CategoryInfo describes a creature category for the web UI and API consumers.
+// It is supplied by generators implementing Describer.
+type CategoryInfo struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Description string   `json:"description"`
+	Criteria    string   `json:"criteria,omitempty"`
+	Types       []string `json:"types,omitempty"`
+	Attributes  Schema   `json:"attributes,omitempty"`
+}
+
+// AttributeField describes a single entry a category stores in Sighting.Attributes.
+// Min and Max bound numeric attributes; Values lists the allowed enum members.
+// Label is the human-readable name shown in the web UI.
+type AttributeField struct {
+	Name        string        `json:"name"`
+	Label       string        `json:"label,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Type        AttributeType `json:"type"`
+	Unit        string        `json:"unit,omitempty"`
+	Values      []string      `json:"values,omitempty"`
+	Min         *float64      `json:"min,omitempty"`
+	Max         *float64      `json:"max,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+}
+
+// Bound returns a pointer to v, for setting an AttributeField's Min or Max.
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// Round rounds v to the given number of decimal places, for attribute values
+// reported to a fixed precision.
+func Round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+// Describer is implemented by generators that can describe their own category.
+// Generators that do not implement it are reported with their category name only.
+type Describer interface {
+	Describe() CategoryInfo
+}
+
+// describe returns the category metadata for a generator registered under category.
+// The registered category name always takes precedence over the self-reported one.
+func describe(category string, generator Generator) CategoryInfo {
+	info := CategoryInfo{Name: category}
+	if d, ok := generator.(Describer); ok {
+		info = d.Describe()
+		info.Name = category
+	}
+	if info.DisplayName == "" {
+		info.DisplayName = category
+	}
+	return info
+}
@@ -0,0 +1,136 @@
+package sighting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidOptions is returned when generation options are malformed or cannot
+// be satisfied by a generator (for example, an unknown type or region).
+var ErrInvalidOptions = errors.New("invalid generation options")
+
+// maxAdaptAttempts bounds how many times an adapted generator is re-run while
+// searching for a sighting that matches the requested region or type.
+const maxAdaptAttempts = 64
+
+// Options carries caller-supplied constraints for a single generation request.
+// Zero values mean "no preference" and leave the choice to the generator.
+type Options struct {
+	Region    string    `json:"region,omitempty"`
+	Location  *Location `json:"location,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	Seed      *int64    `json:"seed,omitempty"`
+}
+
+// ContextGenerator extends Generator with cancellation and generation options.
+// Generators that can honour options natively should implement this interface;
+// others are wrapped with Adapt.
+type ContextGenerator interface {
+	Generator
+	GenerateWithOptions(ctx context.Context, opts Options) (*Sighting, error)
+}
+
+// Adapt returns g as a ContextGenerator. Generators that already implement the
+// extended contract are returned unchanged; plain generators are wrapped so that
+// location and timestamp overrides are applied after generation and region and
+// type constraints are met by regenerating. Seeds cannot be honoured by plain
+// generators and are ignored.
+func Adapt(g Generator) ContextGenerator {
+	if cg, ok := g.(ContextGenerator); ok {
+		return cg
+	}
+	return &adapter{Generator: g}
+}
+
+// adapter wraps a plain Generator to satisfy ContextGenerator.
+type adapter struct {
+	Generator
+}
+
+// GenerateWithOptions calls the wrapped generator until the result matches the
+// requested region and type, then applies location and timestamp overrides.
+func (a *adapter) GenerateWithOptions(ctx context.Context, opts Options) (*Sighting, error) {
+	for attempt := 0; attempt < maxAdaptAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		s, err := a.Generate()
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.Type != "" && s.Type != opts.Type {
+			continue
+		}
+		if opts.Region != "" && opts.Location == nil && !strings.EqualFold(s.Location.Region, opts.Region) {
+			continue
+		}
+
+		if opts.Location != nil {
+			// A generated path no longer starts at the overridden location
+			s.Location = *opts.Location
+			s.Path = nil
+			s.Weather = nil
+		}
+		if !opts.Timestamp.IsZero() {
+			s.Timestamp = opts.Timestamp
+			s.Weather = nil
+		}
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s generator could not satisfy type %q region %q",
+		ErrInvalidOptions, a.Category(), opts.Type, opts.Region)
+}
+
+// ParseOptions builds Options from URL query parameters. Recognised keys are
+// "region", "type", "lat" and "lon" (both required together), "timestamp"
+// (RFC 3339) and "seed" (integer).
+func ParseOptions(values url.Values) (Options, error) {
+	opts := Options{
+		Region: values.Get("region"),
+		Type:   values.Get("type"),
+	}
+
+	lat, lon := values.Get("lat"), values.Get("lon")
+	if lat != "" || lon != "" {
+		latitude, err := strconv.ParseFloat(lat, 64)
+		if err != nil || latitude < -90 || latitude > 90 {
+			return Options{}, fmt.Errorf("%w: lat must be a number between -90 and 90", ErrInvalidOptions)
+		}
+		longitude, err := strconv.ParseFloat(lon, 64)
+		if err != nil || longitude < -180 || longitude > 180 {
+			return Options{}, fmt.Errorf("%w: lon must be a number between -180 and 180", ErrInvalidOptions)
+		}
+		opts.Location = &Location{
+			Latitude:  latitude,
+			Longitude: longitude,
+			Region:    opts.Region,
+		}
+	}
+
+	if ts := values.Get("timestamp"); ts != "" {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return Options{}, fmt.Errorf("%w: timestamp must be RFC 3339", ErrInvalidOptions)
+		}
+		opts.Timestamp = t
+	}
+
+	if seed := values.Get("seed"); seed != "" {
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return Options{}, fmt.Errorf("%w: seed must be an integer", ErrInvalidOptions)
+		}
+		opts.Seed = &n
+	}
+
+	return opts, nil
+}
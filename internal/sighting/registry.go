@@ -1,17 +1,30 @@
 package sighting
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/weather"
 )
 
 // Registry manages thread-safe registration and retrieval of creature generators.
-// It maintains a map of category names to their corresponding generators.
+// It maintains a map of category names to their corresponding generators and
+// reports categories in the order they were registered.
 type Registry struct {
 	mu         sync.RWMutex
 	generators map[string]Generator
+	order      []string // maintain registration order
+	observer   Observer
 }
 
+// Observer is told about every generation made through the registry: the
+// category, how long it took and the error, if any.
+type Observer func(category string, elapsed time.Duration, err error)
+
 // NewRegistry creates a new empty registry for creature generators.
 func NewRegistry() *Registry {
 	return &Registry{
@@ -30,6 +43,7 @@ func (r *Registry) Register(category string, generator Generator) error {
 	}
 
 	r.generators[category] = generator
+	r.order = append(r.order, category)
 	return nil
 }
 
@@ -47,15 +61,152 @@ func (r *Registry) Get(category string) (Generator, error) {
 	return generator, nil
 }
 
-// Categories returns a list of all registered category names.
+// GetContext retrieves the generator for the specified category as a ContextGenerator,
+// adapting plain generators so callers can always pass a context and options.
+func (r *Registry) GetContext(category string) (ContextGenerator, error) {
+	generator, err := r.Get(category)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	observer := r.observer
+	r.mu.RUnlock()
+
+	if observer == nil {
+		return Adapt(generator), nil
+	}
+	return &observed{ContextGenerator: Adapt(generator), category: category, observer: observer}, nil
+}
+
+// SetObserver installs a function that is called after each generation made by
+// generators obtained through GetContext, for example to record metrics.
+func (r *Registry) SetObserver(observer Observer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observer = observer
+}
+
+// Categories returns a list of all registered category names in registration order.
 func (r *Registry) Categories() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	categories := make([]string, 0, len(r.generators))
-	for category := range r.generators {
-		categories = append(categories, category)
+	return slices.Clone(r.order)
+}
+
+// Info returns the metadata describing the specified category.
+// It returns an error if no generator is found for the category.
+func (r *Registry) Info(category string) (CategoryInfo, error) {
+	generator, err := r.Get(category)
+	if err != nil {
+		return CategoryInfo{}, err
+	}
+
+	return describe(category, generator), nil
+}
+
+// Infos returns the metadata for all registered categories in registration order.
+func (r *Registry) Infos() []CategoryInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]CategoryInfo, 0, len(r.order))
+	for _, category := range r.order {
+		infos = append(infos, describe(category, r.generators[category]))
+	}
+
+	return infos
+}
+
+// Normalize converts the attributes of s to the types declared by its category's
+// schema, returning an error wrapping ErrInvalidAttributes if they do not conform,
+// ErrInvalidType if its category declares types and s has none of them, or
+// ErrInvalidStatus if its status is unknown.
+// Sightings generated without weather get the conditions at their place and time,
+// sightings without a status enter the workflow as reported, and sightings without
+// a threat level are assessed if their generator is a ThreatAssessor.
+func (r *Registry) Normalize(s *Sighting) error {
+	generator, err := r.Get(s.Category)
+	if err != nil {
+		return err
+	}
+	info := describe(s.Category, generator)
+
+	attrs, err := info.Attributes.Normalize(s.Attributes)
+	if err != nil {
+		return err
+	}
+
+	if len(info.Types) > 0 && !slices.Contains(info.Types, s.Type) {
+		return fmt.Errorf("%w: %q is not a %s type; use one of %s", ErrInvalidType, s.Type, s.Category, strings.Join(info.Types, ", "))
+	}
+
+	s.Attributes = attrs
+	if s.Weather == nil {
+		conditions := weather.At(s.Location.Latitude, s.Location.Longitude, s.Timestamp)
+		s.Weather = &conditions
+	}
+	if s.Status == "" {
+		s.Status = StatusReported
+	} else {
+		status, err := ParseStatus(string(s.Status))
+		if err != nil {
+			return err
+		}
+		s.Status = status
+	}
+	if s.Threat != "" {
+		threat, err := ParseThreat(string(s.Threat))
+		if err != nil {
+			return err
+		}
+		s.Threat = threat
+	} else if assessor, ok := generator.(ThreatAssessor); ok {
+		s.Threat = assessor.AssessThreat(s)
 	}
+	return nil
+}
+
+// CheckFilters verifies that every filter names an attribute declared by the given
+// category, or by any registered category when category is empty, and that its
+// operator and value suit the attribute's type.
+func (r *Registry) CheckFilters(category string, filters []AttributeFilter) error {
+	infos := r.Infos()
+	for _, f := range filters {
+		declared := false
+		for _, info := range infos {
+			if category != "" && info.Name != category {
+				continue
+			}
+			field, ok := info.Attributes.Field(f.Name)
+			if !ok {
+				continue
+			}
+			if err := f.Check(field); err != nil {
+				return err
+			}
+			declared = true
+		}
+		if !declared {
+			return fmt.Errorf("%w: unknown attribute %q", ErrInvalidFilter, f.Name)
+		}
+	}
+	return nil
+}
+
+// observed wraps a generator to report each generation to an Observer.
+type observed struct {
+	ContextGenerator
+	category string
+	observer Observer
+}
 
-	return categories
+// GenerateWithOptions generates a sighting and reports the outcome.
+func (o *observed) GenerateWithOptions(ctx context.Context, opts Options) (*Sighting, error) {
+	start := time.Now()
+	s, err := o.ContextGenerator.GenerateWithOptions(ctx, opts)
+	o.observer(o.category, time.Since(start), err)
+	return s, err
 }
@@ -0,0 +1,70 @@
+package sighting
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// testGenerator is a category with two declared types and one attribute.
+type testGenerator struct{}
+
+func (testGenerator) Generate() (*Sighting, error) { return &Sighting{Category: "test"}, nil }
+func (testGenerator) Category() string             { return "test" }
+func (testGenerator) Describe() CategoryInfo {
+	return CategoryInfo{
+		Types:      []string{"Wyrm", "Drake"},
+		Attributes: Schema{{Name: "wingspan", Type: AttributeFloat, Min: Bound(0)}},
+	}
+}
+
+// newTestRegistry returns a registry holding testGenerator.
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	if err := r.Register("test", testGenerator{}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return r
+}
+
+func TestNormalize(t *testing.T) {
+	r := newTestRegistry(t)
+
+	tests := []struct {
+		name       string
+		sighting   Sighting
+		wantErr    error
+		wantStatus Status
+	}{
+		{"defaults status", Sighting{Type: "Wyrm"}, nil, StatusReported},
+		{"keeps status", Sighting{Type: "Drake", Status: StatusVerified}, nil, StatusVerified},
+		{"normalizes status", Sighting{Type: "Drake", Status: "Under Review"}, nil, StatusUnderReview},
+		{"unknown status", Sighting{Type: "Wyrm", Status: "bogus"}, ErrInvalidStatus, ""},
+		{"undeclared type", Sighting{Type: "Basilisk"}, ErrInvalidType, ""},
+		{"missing type", Sighting{}, ErrInvalidType, ""},
+		{"bad attribute", Sighting{Type: "Wyrm", Attributes: Attributes{"wingspan": -3.0}}, ErrInvalidAttributes, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.sighting
+			s.Category = "test"
+			s.Timestamp = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+			err := r.Normalize(&s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", s.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNormalizeUnknownCategory(t *testing.T) {
+	r := newTestRegistry(t)
+	if err := r.Normalize(&Sighting{Category: "nope", Type: "Wyrm"}); err == nil {
+		t.Error("Normalize of an unregistered category succeeded")
+	}
+}
@@ -0,0 +1,62 @@
+package sighting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidReport is returned when a witness report is missing required details.
+var ErrInvalidReport = errors.New("invalid witness report")
+
+// Confidences lists the confidence levels a witness can claim for their report.
+var Confidences = []string{"low", "moderate", "high"}
+
+// Limits on submitted witness reports.
+const (
+	maxWitnessName = 100
+	maxReportText  = 4000
+)
+
+// Report is one witness's account of a sighting. Accounts of the same sighting may
+// disagree on the details. An empty Witness means the report was made anonymously.
+type Report struct {
+	ID         string    `json:"id"`
+	Witness    string    `json:"witness,omitempty"`
+	Distance   int       `json:"distance"`   // meters between witness and creature
+	Confidence string    `json:"confidence"` // one of Confidences
+	Text       string    `json:"text"`
+	Timestamp  time.Time `json:"timestamp"` // when the report was made
+}
+
+// WitnessName returns the witness's name, or "Anonymous".
+func (r Report) WitnessName() string {
+	if r.Witness == "" {
+		return "Anonymous"
+	}
+	return r.Witness
+}
+
+// Validate trims the report's text fields and checks them, returning an error
+// wrapping ErrInvalidReport describing the first problem found.
+func (r *Report) Validate() error {
+	r.Witness = strings.TrimSpace(r.Witness)
+	r.Text = strings.TrimSpace(r.Text)
+	r.Confidence = strings.ToLower(strings.TrimSpace(r.Confidence))
+
+	switch {
+	case r.Text == "":
+		return fmt.Errorf("%w: text is required", ErrInvalidReport)
+	case len(r.Text) > maxReportText:
+		return fmt.Errorf("%w: text must be at most %d characters", ErrInvalidReport, maxReportText)
+	case len(r.Witness) > maxWitnessName:
+		return fmt.Errorf("%w: witness name must be at most %d characters", ErrInvalidReport, maxWitnessName)
+	case r.Distance < 0:
+		return fmt.Errorf("%w: distance cannot be negative", ErrInvalidReport)
+	case !slices.Contains(Confidences, r.Confidence):
+		return fmt.Errorf("%w: confidence must be one of %s", ErrInvalidReport, strings.Join(Confidences, ", "))
+	}
+	return nil
+}
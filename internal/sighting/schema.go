@@ -0,0 +1,328 @@
+package sighting
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAttributes is returned when a sighting's attributes do not conform to
+// the schema declared by its category.
+var ErrInvalidAttributes = errors.New("invalid attributes")
+
+// ErrInvalidFilter is returned when an attribute filter references an unknown
+// attribute, uses an unsupported operator, or carries a malformed value.
+var ErrInvalidFilter = errors.New("invalid attribute filter")
+
+// AttributeType identifies how an attribute value is stored and compared.
+type AttributeType string
+
+// Supported attribute types.
+const (
+	AttributeString AttributeType = "string"
+	AttributeInt    AttributeType = "int"
+	AttributeFloat  AttributeType = "float"
+	AttributeBool   AttributeType = "bool"
+	AttributeEnum   AttributeType = "enum"
+)
+
+// Schema declares the attributes a category stores in Sighting.Attributes.
+// Its order is the order attributes are displayed in.
+type Schema []AttributeField
+
+// DisplayAttribute is a formatted attribute ready for rendering.
+type DisplayAttribute struct {
+	Name  string
+	Label string
+	Value string
+}
+
+// Display returns attrs formatted for rendering in schema order. Attributes the
+// schema does not declare follow, sorted by name, so output is always stable.
+func (s Schema) Display(attrs Attributes) []DisplayAttribute {
+	result := make([]DisplayAttribute, 0, len(attrs))
+	for _, field := range s {
+		if value, ok := attrs[field.Name]; ok {
+			result = append(result, DisplayAttribute{
+				Name:  field.Name,
+				Label: field.DisplayLabel(),
+				Value: field.Format(value),
+			})
+		}
+	}
+
+	extra := make([]string, 0)
+	for name := range attrs {
+		if _, ok := s.Field(name); !ok {
+			extra = append(extra, name)
+		}
+	}
+	slices.Sort(extra)
+	for _, name := range extra {
+		result = append(result, DisplayAttribute{
+			Name:  name,
+			Label: name,
+			Value: fmt.Sprintf("%v", attrs[name]),
+		})
+	}
+
+	return result
+}
+
+// DisplayLabel returns the field's label, falling back to its name.
+func (f AttributeField) DisplayLabel() string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return f.Name
+}
+
+// Field returns the declaration for the named attribute.
+func (s Schema) Field(name string) (AttributeField, bool) {
+	for _, f := range s {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return AttributeField{}, false
+}
+
+// Normalize converts attribute values to their declared types and validates them.
+// Numbers decoded from JSON as float64 become ints where the schema says so, and
+// numeric or boolean strings are parsed. Attributes not declared in the schema are
+// rejected. An empty schema accepts any attributes unchanged.
+func (s Schema) Normalize(attrs Attributes) (Attributes, error) {
+	if len(s) == 0 {
+		return attrs, nil
+	}
+
+	normalized := make(Attributes, len(attrs))
+	for name, value := range attrs {
+		field, ok := s.Field(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: undeclared attribute %q", ErrInvalidAttributes, name)
+		}
+		v, err := field.normalize(value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[name] = v
+	}
+
+	for _, field := range s {
+		if field.Required {
+			if _, ok := normalized[field.Name]; !ok {
+				return nil, fmt.Errorf("%w: missing required attribute %q", ErrInvalidAttributes, field.Name)
+			}
+		}
+	}
+
+	return normalized, nil
+}
+
+// Parse converts a submitted form value to the field's declared type, returning an
+// error wrapping ErrInvalidAttributes if it is malformed or out of range.
+func (f AttributeField) Parse(value string) (any, error) {
+	return f.normalize(strings.TrimSpace(value))
+}
+
+// normalize converts a single value to the field's declared type and checks its
+// enum membership and range.
+func (f AttributeField) normalize(value any) (any, error) {
+	switch f.Type {
+	case AttributeInt, AttributeFloat:
+		n, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("%w: attribute %q must be a number", ErrInvalidAttributes, f.Name)
+		}
+		if f.Min != nil && n < *f.Min {
+			return nil, fmt.Errorf("%w: attribute %q below minimum %v", ErrInvalidAttributes, f.Name, *f.Min)
+		}
+		if f.Max != nil && n > *f.Max {
+			return nil, fmt.Errorf("%w: attribute %q above maximum %v", ErrInvalidAttributes, f.Name, *f.Max)
+		}
+		if f.Type == AttributeFloat {
+			return n, nil
+		}
+		if n != math.Trunc(n) {
+			return nil, fmt.Errorf("%w: attribute %q must be an integer", ErrInvalidAttributes, f.Name)
+		}
+		return int(n), nil
+	case AttributeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: attribute %q must be a boolean", ErrInvalidAttributes, f.Name)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("%w: attribute %q must be a boolean", ErrInvalidAttributes, f.Name)
+	case AttributeEnum:
+		v, ok := value.(string)
+		if !ok || !slices.Contains(f.Values, v) {
+			return nil, fmt.Errorf("%w: attribute %q must be one of %s", ErrInvalidAttributes, f.Name, strings.Join(f.Values, ", "))
+		}
+		return v, nil
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: attribute %q must be a string", ErrInvalidAttributes, f.Name)
+		}
+		return v, nil
+	}
+}
+
+// Format renders an attribute value for display, appending the unit if declared.
+func (f AttributeField) Format(value any) string {
+	var s string
+	switch v := value.(type) {
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	if f.Unit != "" {
+		s += " " + f.Unit
+	}
+	return s
+}
+
+// toFloat converts numeric values and numeric strings to float64.
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// FilterOp is a comparison applied by an AttributeFilter.
+type FilterOp string
+
+// Supported filter operators. Ordering operators only apply to numeric attributes.
+const (
+	OpEq  FilterOp = "eq"
+	OpGt  FilterOp = "gt"
+	OpGte FilterOp = "gte"
+	OpLt  FilterOp = "lt"
+	OpLte FilterOp = "lte"
+)
+
+// AttributeFilter selects sightings by comparing one attribute against a value.
+type AttributeFilter struct {
+	Name  string
+	Op    FilterOp
+	Value string
+}
+
+// ParseAttributeFilters builds attribute filters from URL query parameters.
+// "behavior=aggressive" becomes an equality filter and "height_gte=150" a range
+// filter. Keys listed in reserved are skipped.
+func ParseAttributeFilters(values url.Values, reserved ...string) []AttributeFilter {
+	var filters []AttributeFilter
+	for key, vals := range values {
+		if slices.Contains(reserved, key) {
+			continue
+		}
+		name, op := key, OpEq
+		for _, candidate := range []FilterOp{OpGte, OpLte, OpGt, OpLt} {
+			if trimmed, ok := strings.CutSuffix(key, "_"+string(candidate)); ok {
+				name, op = trimmed, candidate
+				break
+			}
+		}
+		for _, v := range vals {
+			filters = append(filters, AttributeFilter{Name: name, Op: op, Value: v})
+		}
+	}
+	slices.SortFunc(filters, func(a, b AttributeFilter) int {
+		return strings.Compare(a.Name+string(a.Op), b.Name+string(b.Op))
+	})
+	return filters
+}
+
+// Check reports whether the filter can be evaluated against field.
+func (f AttributeFilter) Check(field AttributeField) error {
+	switch field.Type {
+	case AttributeInt, AttributeFloat:
+		if _, err := strconv.ParseFloat(f.Value, 64); err != nil {
+			return fmt.Errorf("%w: %s needs a numeric value", ErrInvalidFilter, f.Name)
+		}
+	case AttributeBool:
+		if f.Op != OpEq {
+			return fmt.Errorf("%w: %s only supports equality", ErrInvalidFilter, f.Name)
+		}
+		if _, err := strconv.ParseBool(f.Value); err != nil {
+			return fmt.Errorf("%w: %s needs a boolean value", ErrInvalidFilter, f.Name)
+		}
+	default:
+		if f.Op != OpEq {
+			return fmt.Errorf("%w: %s only supports equality", ErrInvalidFilter, f.Name)
+		}
+	}
+	return nil
+}
+
+// Match reports whether attrs satisfy every filter. Filters on attributes the
+// schema does not declare, or that the sighting does not carry, never match.
+func (s Schema) Match(attrs Attributes, filters []AttributeFilter) bool {
+	for _, f := range filters {
+		field, ok := s.Field(f.Name)
+		if !ok || f.Check(field) != nil {
+			return false
+		}
+		value, ok := attrs[f.Name]
+		if !ok || !f.matches(field, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches compares a single value against the filter.
+func (f AttributeFilter) matches(field AttributeField, value any) bool {
+	switch field.Type {
+	case AttributeInt, AttributeFloat:
+		have, ok := toFloat(value)
+		if !ok {
+			return false
+		}
+		want, _ := strconv.ParseFloat(f.Value, 64)
+		switch f.Op {
+		case OpGt:
+			return have > want
+		case OpGte:
+			return have >= want
+		case OpLt:
+			return have < want
+		case OpLte:
+			return have <= want
+		default:
+			return have == want
+		}
+	case AttributeBool:
+		want, _ := strconv.ParseBool(f.Value)
+		have, ok := value.(bool)
+		return ok && have == want
+	default:
+		return strings.EqualFold(fmt.Sprintf("%v", value), f.Value)
+	}
+}
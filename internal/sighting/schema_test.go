@@ -0,0 +1,110 @@
+package sighting
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// testSchema has one attribute of each type.
+var testSchema = Schema{
+	{Name: "height", Type: AttributeInt, Min: Bound(1), Max: Bound(500), Required: true},
+	{Name: "speed", Type: AttributeFloat, Min: Bound(0)},
+	{Name: "armored", Type: AttributeBool},
+	{Name: "behavior", Type: AttributeEnum, Values: []string{"docile", "aggressive"}},
+	{Name: "color", Type: AttributeString},
+}
+
+func TestSchemaNormalize(t *testing.T) {
+	got, err := testSchema.Normalize(Attributes{
+		"height":   150.0, // as decoded from JSON
+		"speed":    "12.5",
+		"armored":  "true",
+		"behavior": "docile",
+		"color":    "green",
+	})
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	want := Attributes{"height": 150, "speed": 12.5, "armored": true, "behavior": "docile", "color": "green"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize = %#v, want %#v", got, want)
+	}
+
+	for name, attrs := range map[string]Attributes{
+		"missing required": {"speed": 1.0},
+		"undeclared":       {"height": 10, "wings": 2},
+		"below minimum":    {"height": 0},
+		"above maximum":    {"height": 501},
+		"fractional int":   {"height": 10.5},
+		"not a number":     {"height": "tall"},
+		"not a boolean":    {"height": 10, "armored": "maybe"},
+		"not in enum":      {"height": 10, "behavior": "sleepy"},
+		"not a string":     {"height": 10, "color": 7},
+	} {
+		if _, err := testSchema.Normalize(attrs); !errors.Is(err, ErrInvalidAttributes) {
+			t.Errorf("%s: Normalize = %v, want ErrInvalidAttributes", name, err)
+		}
+	}
+}
+
+func TestParseAttributeFilters(t *testing.T) {
+	got := ParseAttributeFilters(url.Values{
+		"height_gte": {"100"},
+		"height_lt":  {"300"},
+		"behavior":   {"aggressive"},
+		"category":   {"kaiju"},
+	}, "category")
+	want := []AttributeFilter{
+		{Name: "behavior", Op: OpEq, Value: "aggressive"},
+		{Name: "height", Op: OpGte, Value: "100"},
+		{Name: "height", Op: OpLt, Value: "300"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAttributeFilters = %+v, want %+v", got, want)
+	}
+}
+
+func TestSchemaMatch(t *testing.T) {
+	attrs := Attributes{"height": 150, "speed": 12.5, "armored": true, "behavior": "aggressive"}
+
+	tests := []struct {
+		filter AttributeFilter
+		want   bool
+	}{
+		{AttributeFilter{"height", OpEq, "150"}, true},
+		{AttributeFilter{"height", OpGte, "150"}, true},
+		{AttributeFilter{"height", OpGt, "150"}, false},
+		{AttributeFilter{"height", OpLte, "150"}, true},
+		{AttributeFilter{"height", OpLt, "150"}, false},
+		{AttributeFilter{"speed", OpGt, "12"}, true},
+		{AttributeFilter{"armored", OpEq, "true"}, true},
+		{AttributeFilter{"armored", OpEq, "false"}, false},
+		{AttributeFilter{"behavior", OpEq, "Aggressive"}, true},
+		{AttributeFilter{"behavior", OpEq, "docile"}, false},
+		{AttributeFilter{"color", OpEq, "green"}, false},     // not carried
+		{AttributeFilter{"wings", OpEq, "2"}, false},         // not declared
+		{AttributeFilter{"height", OpEq, "tall"}, false},     // not a number
+		{AttributeFilter{"behavior", OpGt, "docile"}, false}, // no ordering
+	}
+	for _, tt := range tests {
+		if got := testSchema.Match(attrs, []AttributeFilter{tt.filter}); got != tt.want {
+			t.Errorf("Match(%+v) = %t, want %t", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestAttributeFilterCheck(t *testing.T) {
+	for _, f := range []AttributeFilter{
+		{"height", OpGte, "abc"},
+		{"armored", OpGt, "true"},
+		{"armored", OpEq, "maybe"},
+		{"behavior", OpLt, "docile"},
+	} {
+		field, _ := testSchema.Field(f.Name)
+		if err := f.Check(field); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("Check(%+v) = %v, want ErrInvalidFilter", f, err)
+		}
+	}
+}
@@ -5,19 +5,32 @@ package sighting
 
 import (
 	"time"
+
+	"github.com/pymk/creature-sighting/internal/weather"
 )
 
 // Sighting represents a fictional creature sighting with all relevant details.
 // It includes identification, classification, location, and custom attributes.
+// Path optionally traces the observed movement, ordered from first to last seen;
+// when set, its first point matches Location. Weather records the conditions at
+// Location and Timestamp. Reports holds the individual witness accounts, and Status
+// and History track the sighting through verification. Threat is the assessed
+// danger, empty if the category cannot assess it.
 type Sighting struct {
-	ID          string     `json:"id"`
-	Name        string     `json:"name"`
-	Type        string     `json:"type"`
-	Category    string     `json:"category"`
-	Location    Location   `json:"location"`
-	Description string     `json:"description"`
-	Timestamp   time.Time  `json:"timestamp"`
-	Attributes  Attributes `json:"attributes"`
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Category    string              `json:"category"`
+	Location    Location            `json:"location"`
+	Path        []Location          `json:"path,omitempty"`
+	Description string              `json:"description"`
+	Timestamp   time.Time           `json:"timestamp"`
+	Weather     *weather.Conditions `json:"weather,omitempty"`
+	Attributes  Attributes          `json:"attributes"`
+	Reports     []Report            `json:"reports,omitempty"`
+	Status      Status              `json:"status"`
+	Threat      Threat              `json:"threat,omitempty"`
+	History     []StatusChange      `json:"history,omitempty"`
 }
 
 // Location represents the geographic location of a sighting.
@@ -34,8 +47,20 @@ type Location struct {
 // This allows different creature types to store custom data without schema changes.
 type Attributes map[string]any
 
+// Number returns the named attribute as a number, and whether it is one.
+func (a Attributes) Number(name string) (float64, bool) {
+	return toFloat(a[name])
+}
+
+// Text returns the named attribute if it is a string, or "" otherwise.
+func (a Attributes) Text(name string) string {
+	v, _ := a[name].(string)
+	return v
+}
+
 // Generator defines the interface for creature sighting generators.
 // Implementations must be able to generate random sightings and identify their category.
+// Generators that accept a context and options implement ContextGenerator as well.
 type Generator interface {
 	Generate() (*Sighting, error)
 	Category() string
@@ -0,0 +1,103 @@
+package sighting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidStatus is returned for unknown statuses and disallowed transitions.
+var ErrInvalidStatus = errors.New("invalid status")
+
+// Status is a sighting's place in the verification workflow.
+type Status string
+
+// Sighting statuses, in workflow order.
+const (
+	StatusReported    Status = "reported"
+	StatusUnderReview Status = "under_review"
+	StatusVerified    Status = "verified"
+	StatusDebunked    Status = "debunked"
+	StatusArchived    Status = "archived"
+)
+
+// Statuses lists every status in workflow order.
+var Statuses = []Status{StatusReported, StatusUnderReview, StatusVerified, StatusDebunked, StatusArchived}
+
+// transitions lists the statuses each status may move to. A review can be reopened
+// from a verdict, but archived sightings are final.
+var transitions = map[Status][]Status{
+	StatusReported:    {StatusUnderReview, StatusArchived},
+	StatusUnderReview: {StatusVerified, StatusDebunked, StatusReported},
+	StatusVerified:    {StatusUnderReview, StatusArchived},
+	StatusDebunked:    {StatusUnderReview, StatusArchived},
+	StatusArchived:    {},
+}
+
+// StatusChange records one transition in a sighting's history.
+type StatusChange struct {
+	From      Status    `json:"from"`
+	To        Status    `json:"to"`
+	By        string    `json:"by"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ParseStatus converts a status name to a Status, accepting spaces or hyphens in
+// place of underscores and any letter case.
+func ParseStatus(name string) (Status, error) {
+	normalized := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+	status := Status(normalized)
+	if !slices.Contains(Statuses, status) {
+		return "", fmt.Errorf("%w: unknown status %q", ErrInvalidStatus, name)
+	}
+	return status, nil
+}
+
+// Label returns the status for display, as in "Under Review".
+func (s Status) Label() string {
+	words := strings.Split(string(s), "_")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Next returns the statuses s may move to.
+func (s Status) Next() []Status {
+	return slices.Clone(transitions[s])
+}
+
+// CanTransition reports whether a sighting may move from s to the target status.
+func (s Status) CanTransition(to Status) bool {
+	return slices.Contains(transitions[s], to)
+}
+
+// Transition moves the sighting to a new status and records who made the change and
+// why, returning an error wrapping ErrInvalidStatus if the move is not allowed.
+func (s *Sighting) Transition(to Status, by, reason string, at time.Time) error {
+	by, reason = strings.TrimSpace(by), strings.TrimSpace(reason)
+	switch {
+	case by == "":
+		return fmt.Errorf("%w: reviewer is required", ErrInvalidStatus)
+	case reason == "":
+		return fmt.Errorf("%w: reason is required", ErrInvalidStatus)
+	case !s.Status.CanTransition(to):
+		return fmt.Errorf("%w: cannot move from %s to %s", ErrInvalidStatus, s.Status, to)
+	}
+
+	// Copy so that sightings already handed out keep their own history
+	s.History = append(slices.Clone(s.History), StatusChange{
+		From:      s.Status,
+		To:        to,
+		By:        by,
+		Reason:    reason,
+		Timestamp: at,
+	})
+	s.Status = to
+	return nil
+}
@@ -0,0 +1,114 @@
+package sighting
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := map[string]Status{
+		"reported":     StatusReported,
+		"Under Review": StatusUnderReview,
+		"under-review": StatusUnderReview,
+		" VERIFIED ":   StatusVerified,
+		"debunked":     StatusDebunked,
+		"archived":     StatusArchived,
+	}
+	for name, want := range tests {
+		if got, err := ParseStatus(name); err != nil || got != want {
+			t.Errorf("ParseStatus(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "bogus", "under review please"} {
+		if _, err := ParseStatus(name); !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("ParseStatus(%q) = %v, want ErrInvalidStatus", name, err)
+		}
+	}
+}
+
+// TestCanTransition checks every pair of statuses against the workflow.
+func TestCanTransition(t *testing.T) {
+	allowed := map[[2]Status]bool{
+		{StatusReported, StatusUnderReview}: true,
+		{StatusReported, StatusArchived}:    true,
+		{StatusUnderReview, StatusVerified}: true,
+		{StatusUnderReview, StatusDebunked}: true,
+		{StatusUnderReview, StatusReported}: true,
+		{StatusVerified, StatusUnderReview}: true,
+		{StatusVerified, StatusArchived}:    true,
+		{StatusDebunked, StatusUnderReview}: true,
+		{StatusDebunked, StatusArchived}:    true,
+	}
+	for _, from := range Statuses {
+		for _, to := range Statuses {
+			if got, want := from.CanTransition(to), allowed[[2]Status{from, to}]; got != want {
+				t.Errorf("%s.CanTransition(%s) = %t, want %t", from, to, got, want)
+			}
+		}
+		for _, next := range from.Next() {
+			if !from.CanTransition(next) {
+				t.Errorf("%s.Next() lists %s, which it cannot move to", from, next)
+			}
+		}
+	}
+	if len(StatusArchived.Next()) != 0 {
+		t.Errorf("archived sightings can move to %v, want none", StatusArchived.Next())
+	}
+}
+
+func TestTransition(t *testing.T) {
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	s := Sighting{Status: StatusReported}
+	handedOut := s
+
+	if err := s.Transition(StatusUnderReview, " okafor ", " Footage looks real ", at); err != nil {
+		t.Fatalf("Transition to under review: %v", err)
+	}
+	if err := s.Transition(StatusVerified, "vance", "Second witness", at.Add(time.Hour)); err != nil {
+		t.Fatalf("Transition to verified: %v", err)
+	}
+
+	if s.Status != StatusVerified {
+		t.Errorf("status = %s, want verified", s.Status)
+	}
+	want := []StatusChange{
+		{From: StatusReported, To: StatusUnderReview, By: "okafor", Reason: "Footage looks real", Timestamp: at},
+		{From: StatusUnderReview, To: StatusVerified, By: "vance", Reason: "Second witness", Timestamp: at.Add(time.Hour)},
+	}
+	if !slices.Equal(s.History, want) {
+		t.Errorf("history = %+v, want %+v", s.History, want)
+	}
+	if handedOut.History != nil {
+		t.Error("transition changed the history of an earlier copy")
+	}
+}
+
+func TestTransitionRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   Status
+		to     Status
+		by     string
+		reason string
+	}{
+		{"skips review", StatusReported, StatusVerified, "okafor", "Looks real"},
+		{"leaves archive", StatusArchived, StatusUnderReview, "okafor", "Reopening"},
+		{"stays put", StatusVerified, StatusVerified, "okafor", "Still real"},
+		{"no reviewer", StatusReported, StatusUnderReview, "  ", "Looks real"},
+		{"no reason", StatusReported, StatusUnderReview, "okafor", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Sighting{Status: tt.from}
+			err := s.Transition(tt.to, tt.by, tt.reason, time.Now())
+			if !errors.Is(err, ErrInvalidStatus) {
+				t.Errorf("Transition = %v, want ErrInvalidStatus", err)
+			}
+			if s.Status != tt.from || len(s.History) != 0 {
+				t.Errorf("rejected transition left status %s with %d changes", s.Status, len(s.History))
+			}
+		})
+	}
+}
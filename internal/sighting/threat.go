@@ -0,0 +1,63 @@
+package sighting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidThreat is returned for unknown threat levels.
+var ErrInvalidThreat = errors.New("invalid threat level")
+
+// Threat is the assessed danger a sighting poses to people and infrastructure.
+type Threat string
+
+// Threat levels, from least to most severe.
+const (
+	ThreatLow      Threat = "low"
+	ThreatGuarded  Threat = "guarded"
+	ThreatElevated Threat = "elevated"
+	ThreatHigh     Threat = "high"
+	ThreatSevere   Threat = "severe"
+)
+
+// Threats lists every threat level from least to most severe.
+var Threats = []Threat{ThreatLow, ThreatGuarded, ThreatElevated, ThreatHigh, ThreatSevere}
+
+// ThreatAssessor is implemented by generators that can judge the threat level of
+// sightings in their category from the sighting's type and attributes.
+// Registry.Normalize uses it to fill in sightings that carry no threat level.
+type ThreatAssessor interface {
+	AssessThreat(s *Sighting) Threat
+}
+
+// ParseThreat converts a threat level name to a Threat, ignoring case.
+func ParseThreat(name string) (Threat, error) {
+	threat := Threat(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(Threats, threat) {
+		return "", fmt.Errorf("%w: unknown threat level %q", ErrInvalidThreat, name)
+	}
+	return threat, nil
+}
+
+// Label returns the threat level for display, as in "Elevated".
+func (t Threat) Label() string {
+	if t == "" {
+		return "Unassessed"
+	}
+	return strings.ToUpper(string(t[:1])) + string(t[1:])
+}
+
+// AtLeast reports whether t is as severe as min. Unassessed sightings are below
+// every level.
+func (t Threat) AtLeast(min Threat) bool {
+	return slices.Index(Threats, t) >= slices.Index(Threats, min)
+}
+
+// Raise returns the level steps above t, or below it for negative steps, kept
+// within the known levels. Unassessed threats are treated as low.
+func (t Threat) Raise(steps int) Threat {
+	i := max(slices.Index(Threats, t), 0) + steps
+	return Threats[min(max(i, 0), len(Threats)-1)]
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// storageFile is the on-disk form of a storage snapshot.
+type storageFile struct {
+	Sightings []sighting.Sighting `json:"sightings"`
+}
+
+// Load adds the sightings saved in the file at path, in their saved order,
+// skipping any whose ID is already stored. Listeners are not told about them,
+// since they are not new. It returns the number added. The error wraps
+// fs.ErrNotExist if there is no file.
+func (s *InMemoryStorage) Load(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sightings: %w", err)
+	}
+
+	var file storageFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("failed to parse sightings in %s: %w", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, entry := range file.Sightings {
+		if _, exists := s.sightings[entry.ID]; exists {
+			continue
+		}
+		s.insert(entry)
+		added++
+	}
+	return added, nil
+}
+
+// Save writes every stored sighting to the file at path in the order they were
+// added. The file is replaced atomically, so a failed save leaves the previous
+// snapshot intact.
+func (s *InMemoryStorage) Save(path string) error {
+	sightings := s.GetAll()
+	slices.Reverse(sightings)
+
+	data, err := json.MarshalIndent(storageFile{Sightings: sightings}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sightings-*")
+	if err != nil {
+		return fmt.Errorf("failed to save sightings: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save sightings: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save sightings: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save sightings: %w", err)
+	}
+	return nil
+}
@@ -3,18 +3,31 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pymk/creature-sighting/internal/sighting"
 )
 
+// ErrNotFound is returned when no sighting has the requested ID.
+var ErrNotFound = errors.New("sighting not found")
+
+// lockPollInterval is how often CheckWritable tries the write lock.
+const lockPollInterval = 10 * time.Millisecond
+
 // InMemoryStorage provides thread-safe in-memory storage for sightings.
 // It maintains both a map for fast lookups and a slice for insertion order.
 type InMemoryStorage struct {
 	mu        sync.RWMutex
 	sightings map[string]sighting.Sighting
-	order     []string // maintain insertion order
+	order     []string       // maintain insertion order
+	names     map[string]int // lower-cased creature name -> number of sightings
+	listeners []func(sighting.Sighting)
 }
 
 // NewInMemoryStorage creates a new empty in-memory storage instance.
@@ -22,16 +35,45 @@ func NewInMemoryStorage() *InMemoryStorage {
 	return &InMemoryStorage{
 		sightings: make(map[string]sighting.Sighting),
 		order:     make([]string, 0),
+		names:     make(map[string]int),
+	}
+}
+
+// Add stores a sighting in the storage, maintaining insertion order. Sightings
+// without a status enter the workflow as reported. Listeners registered with
+// OnAdd are told about the sighting once it is stored.
+func (s *InMemoryStorage) Add(entry sighting.Sighting) {
+	s.mu.Lock()
+	entry = s.insert(entry)
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(entry)
 	}
 }
 
-// Add stores a sighting in the storage, maintaining insertion order.
-func (s *InMemoryStorage) Add(sighting sighting.Sighting) {
+// OnAdd registers a function called with every sighting added from now on. It
+// runs on the adding goroutine after the storage is unlocked, so it may read
+// the storage but should hand slow work to another goroutine.
+func (s *InMemoryStorage) OnAdd(listener func(sighting.Sighting)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.sightings[sighting.ID] = sighting
-	s.order = append(s.order, sighting.ID)
+	s.listeners = append(slices.Clip(s.listeners), listener)
+}
+
+// insert stores a sighting without notifying listeners and returns it as stored.
+// The caller must hold the write lock.
+func (s *InMemoryStorage) insert(entry sighting.Sighting) sighting.Sighting {
+	if entry.Status == "" {
+		entry.Status = sighting.StatusReported
+	}
+
+	s.sightings[entry.ID] = entry
+	s.order = append(s.order, entry.ID)
+	s.names[strings.ToLower(entry.Name)]++
+	return entry
 }
 
 // Get retrieves a sighting by ID, returning the sighting and whether it exists.
@@ -75,6 +117,85 @@ func (s *InMemoryStorage) GetByCategory(category string) []sighting.Sighting {
 	return result
 }
 
+// Locations returns the distinct places sightings were made, most recently seen
+// first. Places are distinguished by city and country.
+func (s *InMemoryStorage) Locations() []sighting.Location {
+	seen := make(map[string]bool)
+	locations := make([]sighting.Location, 0)
+
+	for _, entry := range s.GetAll() {
+		key := fmt.Sprintf("%s,%s", entry.Location.City, entry.Location.Country)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		locations = append(locations, entry.Location)
+	}
+	return locations
+}
+
+// Reports returns the witness reports for a sighting in the order they were made,
+// and whether the sighting exists.
+func (s *InMemoryStorage) Reports(id string) ([]sighting.Report, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, exists := s.sightings[id]
+	if !exists {
+		return nil, false
+	}
+	return slices.Clone(stored.Reports), true
+}
+
+// AddReport appends a witness report to a sighting, assigning its ID and, if unset,
+// its timestamp. It returns the stored report and whether the sighting exists.
+func (s *InMemoryStorage) AddReport(id string, report sighting.Report) (sighting.Report, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.sightings[id]
+	if !exists {
+		return sighting.Report{}, false
+	}
+
+	report.ID = fmt.Sprintf("%s-report-%d", id, len(stored.Reports)+1)
+	if report.Timestamp.IsZero() {
+		report.Timestamp = time.Now()
+	}
+	// Copy so that sightings already handed out keep their own report slice
+	stored.Reports = append(slices.Clone(stored.Reports), report)
+	s.sightings[id] = stored
+
+	return report, true
+}
+
+// Transition moves a sighting to a new status on behalf of a reviewer and returns
+// the updated sighting. It returns ErrNotFound for unknown IDs and an error wrapping
+// sighting.ErrInvalidStatus for disallowed transitions.
+func (s *InMemoryStorage) Transition(id string, to sighting.Status, by, reason string) (sighting.Sighting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.sightings[id]
+	if !exists {
+		return sighting.Sighting{}, ErrNotFound
+	}
+	if err := stored.Transition(to, by, reason, time.Now()); err != nil {
+		return sighting.Sighting{}, err
+	}
+	s.sightings[id] = stored
+
+	return stored, nil
+}
+
+// HasName reports whether any stored sighting has the given creature name, ignoring case.
+func (s *InMemoryStorage) HasName(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.names[strings.ToLower(name)] > 0
+}
+
 // Count returns the total number of stored sightings.
 func (s *InMemoryStorage) Count() int {
 	s.mu.RLock()
@@ -83,6 +204,25 @@ func (s *InMemoryStorage) Count() int {
 	return len(s.sightings)
 }
 
+// CheckWritable reports whether the storage can accept writes, by taking its write
+// lock before ctx is done. A lock held indefinitely, for example by a stuck
+// writer, makes the storage unwritable. The lock is tried rather than waited for,
+// so a failed check leaves nothing queued behind the writer to block readers.
+func (s *InMemoryStorage) CheckWritable(ctx context.Context) error {
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for !s.mu.TryLock() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("storage write lock unavailable: %w", ctx.Err())
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
 // Clear removes all sightings from storage.
 func (s *InMemoryStorage) Clear() {
 	s.mu.Lock()
@@ -90,21 +230,29 @@ func (s *InMemoryStorage) Clear() {
 
 	s.sightings = make(map[string]sighting.Sighting)
 	s.order = make([]string, 0)
+	s.names = make(map[string]int)
 }
 
 // GenerateInitialSightings creates demo sightings spread across recent days.
 // This populates the storage with sample data for demonstration purposes.
-func (s *InMemoryStorage) GenerateInitialSightings(registry *sighting.Registry) {
-	// Generate 5 initial kaiju sightings
-	for i := 0; i < 5; i++ {
-		if gen, err := registry.Get("kaiju"); err == nil {
-			sighting, err := gen.Generate()
+func (s *InMemoryStorage) GenerateInitialSightings(registry *sighting.Registry, categories ...string) {
+	for _, category := range categories {
+		gen, err := registry.GetContext(category)
+		if err != nil {
+			continue
+		}
+		// Generate 5 initial sightings per category
+		for i := 0; i < 5; i++ {
+			// Spread timestamps across last few days (6 hours apart)
+			opts := sighting.Options{Timestamp: time.Now().Add(-time.Duration(i*6) * time.Hour)}
+			generated, err := gen.GenerateWithOptions(context.Background(), opts)
 			if err != nil {
 				continue
 			}
-			// Spread timestamps across last few days (6 hours apart)
-			sighting.Timestamp = time.Now().Add(-time.Duration(i*6) * time.Hour)
-			s.Add(*sighting)
+			if err := registry.Normalize(generated); err != nil {
+				continue
+			}
+			s.Add(*generated)
 		}
 	}
 }
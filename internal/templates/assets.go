@@ -0,0 +1,12 @@
+package templates
+
+// assetURL resolves the name of a static asset to the URL pages link to.
+var assetURL = func(name string) string {
+	return "/static/" + name
+}
+
+// UseAssets makes pages link to static assets through resolve, typically an
+// assets.Assets URL method giving content-hashed URLs. Call it before serving.
+func UseAssets(resolve func(name string) string) {
+	assetURL = resolve
+}
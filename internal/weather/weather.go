@@ -0,0 +1,239 @@
+// Package weather derives plausible environmental conditions for a place and time.
+// Conditions are synthetic but deterministic: the same coordinates and instant always
+// yield the same weather, so a sighting's conditions can be recomputed at any time.
+// Sun and moon positions are astronomical approximations; temperature, cloud and
+// precipitation follow a simple climatology perturbed by hashed daily noise.
+package weather
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/geo"
+)
+
+// Daylight states, from the sun's elevation.
+const (
+	Day      = "day"
+	Twilight = "twilight"
+	Night    = "night"
+)
+
+// Sky states.
+const (
+	SkyClear        = "clear"
+	SkyPartlyCloudy = "partly cloudy"
+	SkyOvercast     = "overcast"
+	SkyFog          = "fog"
+)
+
+// Precipitation kinds.
+const (
+	PrecipNone         = "none"
+	PrecipDrizzle      = "drizzle"
+	PrecipRain         = "rain"
+	PrecipHeavyRain    = "heavy rain"
+	PrecipThunderstorm = "thunderstorm"
+	PrecipSleet        = "sleet"
+	PrecipSnow         = "snow"
+)
+
+// synodicMonth is the mean length of a lunar cycle in days.
+const synodicMonth = 29.530588853
+
+// knownNewMoon is a reference new moon used to compute the lunar age.
+var knownNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// moonPhases names the eight principal phases, starting from new moon.
+var moonPhases = []string{
+	"new moon", "waxing crescent", "first quarter", "waxing gibbous",
+	"full moon", "waning gibbous", "last quarter", "waning crescent",
+}
+
+// Conditions describes the environment at a sighting.
+type Conditions struct {
+	Temperature      float64 `json:"temperature"`       // degrees Celsius
+	Precipitation    string  `json:"precipitation"`     // one of the Precip constants
+	Sky              string  `json:"sky"`               // one of the Sky constants
+	Visibility       float64 `json:"visibility"`        // kilometres
+	Daylight         string  `json:"daylight"`          // day, twilight or night
+	SunElevation     float64 `json:"sun_elevation"`     // degrees above the horizon
+	MoonPhase        string  `json:"moon_phase"`        // e.g. "waxing gibbous"
+	MoonIllumination float64 `json:"moon_illumination"` // fraction of the disc lit, 0 to 1
+}
+
+// At returns the conditions at the coordinates and instant.
+func At(lat, lon float64, t time.Time) Conditions {
+	t = t.UTC()
+	hour := geo.LocalSolarHour(t, lon)
+	day := t.YearDay()
+
+	elevation := sunElevation(lat, day, hour)
+	phase, illumination := moon(t)
+
+	temperature := temperatureAt(lat, lon, day, hour, t)
+	sky := skyAt(lat, lon, t)
+	precipitation := precipitationAt(lat, lon, t, hour, sky, temperature)
+	if precipitation != PrecipNone && sky != SkyFog {
+		sky = SkyOvercast
+	}
+
+	return Conditions{
+		Temperature:      math.Round(temperature*10) / 10,
+		Precipitation:    precipitation,
+		Sky:              sky,
+		Visibility:       visibility(lat, lon, t, sky, precipitation),
+		Daylight:         daylight(elevation),
+		SunElevation:     math.Round(elevation*10) / 10,
+		MoonPhase:        phase,
+		MoonIllumination: math.Round(illumination*100) / 100,
+	}
+}
+
+// sunElevation approximates the sun's angle above the horizon in degrees.
+func sunElevation(lat float64, day int, hour float64) float64 {
+	declination := -23.44 * math.Cos(2*math.Pi/365*float64(day+10))
+	hourAngle := 15 * (hour - 12)
+
+	phi, delta, h := radians(lat), radians(declination), radians(hourAngle)
+	sin := math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Cos(h)
+	return math.Asin(math.Max(-1, math.Min(1, sin))) * 180 / math.Pi
+}
+
+// daylight classifies the sun's elevation; civil twilight ends at -6 degrees.
+func daylight(elevation float64) string {
+	switch {
+	case elevation > 0:
+		return Day
+	case elevation > -6:
+		return Twilight
+	default:
+		return Night
+	}
+}
+
+// moon returns the phase name and illuminated fraction at t.
+func moon(t time.Time) (string, float64) {
+	age := math.Mod(t.Sub(knownNewMoon).Hours()/24, synodicMonth)
+	if age < 0 {
+		age += synodicMonth
+	}
+	fraction := age / synodicMonth
+	illumination := (1 - math.Cos(2*math.Pi*fraction)) / 2
+
+	idx := int(math.Floor(fraction*8+0.5)) % len(moonPhases)
+	return moonPhases[idx], illumination
+}
+
+// temperatureAt combines a latitude climatology with seasonal and daily cycles,
+// damped over the sea, plus a daily anomaly shared across a 5-degree cell.
+func temperatureAt(lat, lon float64, day int, hour float64, t time.Time) float64 {
+	mean := 27 - 0.006*lat*lat
+	seasonal := 0.25 * math.Abs(lat)
+	diurnal := 4.0
+	if !geo.IsLand(lat, lon) {
+		seasonal /= 2
+		diurnal /= 2
+	}
+
+	// Northern summers peak in late July; southern ones six months later
+	season := math.Cos(2 * math.Pi * float64(day-200) / 365)
+	if lat < 0 {
+		season = -season
+	}
+	daily := math.Cos(2 * math.Pi * (hour - 15) / 24)
+	anomaly := (noise("temperature", cell(lat), cell(lon), t.Format(time.DateOnly)) - 0.5) * 6
+
+	return mean + seasonal*season + diurnal*daily + anomaly
+}
+
+// skyAt picks the cloud cover for the cell and six-hour block.
+func skyAt(lat, lon float64, t time.Time) string {
+	u := noise("sky", cell(lat), cell(lon), t.Format(time.DateOnly), t.Hour()/6)
+	switch {
+	case u < 0.04, u < 0.06 && !geo.IsLand(lat, lon):
+		return SkyFog
+	case u < 0.45:
+		return SkyClear
+	case u < 0.75:
+		return SkyPartlyCloudy
+	default:
+		return SkyOvercast
+	}
+}
+
+// precipitationAt decides whether it is raining or snowing, with odds by climate
+// zone and afternoon storms in the tropics.
+func precipitationAt(lat, lon float64, t time.Time, hour float64, sky string, temperature float64) string {
+	chance := map[string]float64{
+		geo.ZoneTropical:  0.35,
+		geo.ZoneTemperate: 0.3,
+		geo.ZoneSubpolar:  0.35,
+		geo.ZonePolar:     0.2,
+	}[geo.Zone(lat)]
+	if sky == SkyClear {
+		return PrecipNone
+	}
+
+	u := noise("precipitation", cell(lat), cell(lon), t.Format(time.DateOnly), t.Hour()/6)
+	if u >= chance {
+		return PrecipNone
+	}
+	intensity := u / chance
+
+	switch {
+	case temperature < -1:
+		return PrecipSnow
+	case temperature < 2:
+		return PrecipSleet
+	case intensity > 0.8 && geo.Zone(lat) == geo.ZoneTropical && hour >= 13 && hour < 20:
+		return PrecipThunderstorm
+	case intensity > 0.7:
+		return PrecipHeavyRain
+	case intensity > 0.3:
+		return PrecipRain
+	default:
+		return PrecipDrizzle
+	}
+}
+
+// visibility estimates how far an observer could see, in kilometres.
+func visibility(lat, lon float64, t time.Time, sky, precipitation string) float64 {
+	base := map[string]float64{
+		PrecipNone:         20,
+		PrecipDrizzle:      10,
+		PrecipRain:         6,
+		PrecipHeavyRain:    2.5,
+		PrecipThunderstorm: 2,
+		PrecipSleet:        3,
+		PrecipSnow:         1.5,
+	}[precipitation]
+	if sky == SkyFog {
+		base = 0.5
+	}
+
+	// Vary by up to a quarter either way so neighbouring reports differ
+	u := noise("visibility", cell(lat), cell(lon), t.Format(time.DateOnly), t.Hour())
+	return math.Round(base*(0.75+u/2)*10) / 10
+}
+
+// noise returns a deterministic pseudo-random value in [0, 1) for the given key.
+func noise(parts ...any) float64 {
+	h := fnv.New64a()
+	for _, p := range parts {
+		fmt.Fprint(h, p, "|")
+	}
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+// cell snaps a coordinate to a 5-degree grid so nearby sightings share weather.
+func cell(deg float64) int {
+	return int(math.Floor(deg / 5))
+}
+
+// radians converts degrees to radians.
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
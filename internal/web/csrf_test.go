@@ -0,0 +1,64 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRFToken(t *testing.T) {
+	// A browser without a token is issued one
+	rec := httptest.NewRecorder()
+	token := csrfToken(rec, httptest.NewRequest(http.MethodGet, "/sighting/new", nil))
+	cookies := rec.Result().Cookies()
+	if token == "" || len(cookies) != 1 || cookies[0].Name != csrfCookie || cookies[0].Value != token {
+		t.Fatalf("issued token %q with cookies %v, want the token in a %s cookie", token, cookies, csrfCookie)
+	}
+	if !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookie is not HttpOnly and SameSite=Strict: %v", cookies[0])
+	}
+
+	// A browser with a token keeps it
+	req := httptest.NewRequest(http.MethodGet, "/sighting/new", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookie, Value: token})
+	rec = httptest.NewRecorder()
+	if got := csrfToken(rec, req); got != token {
+		t.Errorf("token for a browser that has one = %q, want %q", got, token)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("a new cookie was set for a browser that has a token")
+	}
+}
+
+func TestValidCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		field  string
+		want   bool
+	}{
+		{"matching", "abc123", "abc123", true},
+		{"mismatched", "abc123", "abc124", false},
+		{"no field", "abc123", "", false},
+		{"no cookie", "", "abc123", false},
+		{"neither", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.field != "" {
+				form.Set(csrfField, tt.field)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/sighting/new", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.cookie})
+			}
+			if got := validCSRF(req); got != tt.want {
+				t.Errorf("validCSRF = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
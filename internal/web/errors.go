@@ -0,0 +1,37 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/middleware"
+	"github.com/pymk/creature-sighting/internal/templates"
+)
+
+// ServerError answers 500 Internal Server Error after a handler has failed
+// unexpectedly. Web pages get an error page quoting the request ID; API clients
+// get the same message as plain text.
+func ServerError(w http.ResponseWriter, r *http.Request) {
+	id := middleware.RequestIDFrom(r.Context())
+	message := "Internal server error"
+	if id != "" {
+		message = fmt.Sprintf("Internal server error (request ID %s)", id)
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+
+	// Render to a buffer first so a failing page still gets a plain response
+	var buf bytes.Buffer
+	if err := templates.ServerError(id).Render(r.Context(), &buf); err != nil {
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(buf.Bytes())
+}
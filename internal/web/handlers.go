@@ -3,26 +3,40 @@
 package web
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/pymk/creature-sighting/internal/auth"
+	"github.com/pymk/creature-sighting/internal/geofence"
 	"github.com/pymk/creature-sighting/internal/sighting"
 	"github.com/pymk/creature-sighting/internal/storage"
 	"github.com/pymk/creature-sighting/internal/templates"
+	"github.com/pymk/creature-sighting/internal/webhook"
 )
 
 // Handler provides HTTP handlers for web UI endpoints.
 type Handler struct {
-	registry *sighting.Registry
-	storage  *storage.InMemoryStorage
+	registry  *sighting.Registry
+	storage   *storage.InMemoryStorage
+	auth      *auth.Authenticator
+	webhooks  *webhook.Dispatcher
+	geofences *geofence.Store
 }
 
-// NewHandler creates a new web handler with the given registry and storage.
-func NewHandler(registry *sighting.Registry, storage *storage.InMemoryStorage) *Handler {
+// NewHandler creates a new web handler with the given registry and storage,
+// signing users in against authn and showing the deliveries of webhooks and the
+// alerts raised by geofences.
+func NewHandler(registry *sighting.Registry, storage *storage.InMemoryStorage, authn *auth.Authenticator, webhooks *webhook.Dispatcher, geofences *geofence.Store) *Handler {
 	return &Handler{
-		registry: registry,
-		storage:  storage,
+		registry:  registry,
+		storage:   storage,
+		auth:      authn,
+		webhooks:  webhooks,
+		geofences: geofences,
 	}
 }
 
@@ -40,7 +54,7 @@ func (h *Handler) HandleHome(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleSightings renders the sightings list page with optional category filtering.
-// Accepts "category" query parameter to filter sightings by category.
+// Accepts "category" and "status" query parameters to filter sightings.
 func (h *Handler) HandleSightings(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -48,6 +62,15 @@ func (h *Handler) HandleSightings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	category := r.URL.Query().Get("category")
+	var status sighting.Status
+	if name := r.URL.Query().Get("status"); name != "" {
+		var err error
+		if status, err = sighting.ParseStatus(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	var sightings []sighting.Sighting
 
 	if category != "" {
@@ -56,47 +79,160 @@ func (h *Handler) HandleSightings(w http.ResponseWriter, r *http.Request) {
 		sightings = h.storage.GetAll()
 	}
 
+	if status != "" {
+		sightings = slices.DeleteFunc(sightings, func(s sighting.Sighting) bool {
+			return s.Status != status
+		})
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := templates.SightingsList(sightings).Render(r.Context(), w); err != nil {
+	if err := templates.SightingsList(sightings, category, status).Render(r.Context(), w); err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
-// HandleSightingDetail renders the detail page for a specific sighting.
-// Extracts sighting ID from URL path and handles special "random" case.
+// HandleSightingDetail renders the detail page for a specific sighting at
+// /sighting/{id}. It only reads: reports and review decisions are submitted to
+// HandleAddReport and HandleChangeStatus, which are routed by method so that
+// they can require a role.
 func (h *Handler) HandleSightingDetail(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	// Extract ID from URL path (e.g., /sighting/kaiju-123 -> kaiju-123)
-	path := strings.TrimPrefix(r.URL.Path, "/sighting/")
-	if path == "" {
+	id := strings.TrimPrefix(r.URL.Path, "/sighting/")
+	if id == "" {
 		http.Error(w, "Sighting ID required", http.StatusBadRequest)
 		return
 	}
+	if strings.Contains(id, "/") {
+		http.Error(w, "Sighting not found", http.StatusNotFound)
+		return
+	}
+
+	sighting, exists := h.storage.Get(id)
+	if !exists {
+		http.Error(w, "Sighting not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderSightingDetail(w, r, sighting, templates.DetailForms{}, http.StatusOK)
+}
 
-	// Handle special case for generating new random sighting
-	if path == "random" {
-		h.HandleRandomSighting(w, r)
+// HandleAddReport adds a witness report submitted from the sighting detail page
+// via POST /sighting/{id}/reports, then redirects back to the report list. Invalid
+// submissions re-render the detail page with the error and the values entered.
+func (h *Handler) HandleAddReport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validCSRF(r) {
+		http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
 		return
 	}
 
-	sighting, exists := h.storage.Get(path)
+	s, exists := h.storage.Get(id)
 	if !exists {
 		http.Error(w, "Sighting not found", http.StatusNotFound)
 		return
 	}
 
+	form := templates.ReportForm{
+		Witness:    r.PostFormValue("witness"),
+		Distance:   r.PostFormValue("distance"),
+		Confidence: r.PostFormValue("confidence"),
+		Text:       r.PostFormValue("text"),
+	}
+
+	report := sighting.Report{
+		Witness:    form.Witness,
+		Confidence: form.Confidence,
+		Text:       form.Text,
+	}
+	distance, err := strconv.Atoi(strings.TrimSpace(form.Distance))
+	if err != nil {
+		err = fmt.Errorf("%w: distance must be a whole number of meters", sighting.ErrInvalidReport)
+	} else {
+		report.Distance = distance
+		err = report.Validate()
+	}
+	if err != nil {
+		form.Error = err.Error()
+		h.renderSightingDetail(w, r, s, templates.DetailForms{Report: form}, http.StatusBadRequest)
+		return
+	}
+
+	if _, exists := h.storage.AddReport(id, report); !exists {
+		http.Error(w, "Sighting not found", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/sighting/"+id+"#reports", http.StatusSeeOther)
+}
+
+// HandleChangeStatus applies a review decision submitted from the sighting detail
+// page via POST /sighting/{id}/status on behalf of the signed-in user, then
+// redirects back to the review history. Rejected decisions re-render the detail
+// page with the error and the values entered.
+func (h *Handler) HandleChangeStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validCSRF(r) {
+		http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+		return
+	}
+
+	form := templates.ReviewForm{
+		Status: r.PostFormValue("status"),
+		Reason: r.PostFormValue("reason"),
+	}
+	reviewer, _ := auth.UserFrom(r.Context())
+
+	status, err := sighting.ParseStatus(form.Status)
+	if err == nil {
+		_, err = h.storage.Transition(id, status, reviewer.Name, form.Reason)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Sighting not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s, exists := h.storage.Get(id)
+		if !exists {
+			http.Error(w, "Sighting not found", http.StatusNotFound)
+			return
+		}
+		form.Error = err.Error()
+		h.renderSightingDetail(w, r, s, templates.DetailForms{Review: form}, http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/sighting/"+id+"#review", http.StatusSeeOther)
+}
+
+// renderSightingDetail writes the detail page for s with its forms showing the
+// values and errors in forms.
+func (h *Handler) renderSightingDetail(w http.ResponseWriter, r *http.Request, s sighting.Sighting, forms templates.DetailForms, status int) {
+	// Unregistered categories have no schema; attributes then render unformatted
+	info, _ := h.registry.Info(s.Category)
+	forms.CSRF = csrfToken(w, r)
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := templates.SightingDetail(sighting).Render(r.Context(), w); err != nil {
+	w.WriteHeader(status)
+	if err := templates.SightingDetail(s, info.Attributes, forms).Render(r.Context(), w); err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
 // HandleRandomSighting generates a new random sighting and redirects to its detail page.
-// Accepts optional "category" query parameter, defaults to "kaiju".
+// Accepts optional "category" query parameter, defaults to "kaiju", plus the
+// generation options understood by sighting.ParseOptions.
 func (h *Handler) HandleRandomSighting(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -109,23 +245,38 @@ func (h *Handler) HandleRandomSighting(w http.ResponseWriter, r *http.Request) {
 		category = "kaiju"
 	}
 
-	generator, err := h.registry.Get(category)
+	opts, err := sighting.ParseOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	generator, err := h.registry.GetContext(category)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Category not found: %s", category), http.StatusNotFound)
 		return
 	}
 
-	sighting, err := generator.Generate()
+	s, err := generator.GenerateWithOptions(r.Context(), opts)
+	if errors.Is(err, sighting.ErrInvalidOptions) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to generate sighting: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if err := h.registry.Normalize(s); err != nil {
+		http.Error(w, fmt.Sprintf("Generated sighting is invalid: %v", err), http.StatusInternalServerError)
+		return
+	}
+
 	// Store the generated sighting
-	h.storage.Add(*sighting)
+	h.storage.Add(*s)
 
 	// Redirect to the sighting detail page
-	http.Redirect(w, r, fmt.Sprintf("/sighting/%s", sighting.ID), http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("/sighting/%s", s.ID), http.StatusSeeOther)
 }
 
 // HandleLocations renders the locations list page.
@@ -135,23 +286,28 @@ func (h *Handler) HandleLocations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get unique locations from all sightings
-	allSightings := h.storage.GetAll()
-	locationMap := make(map[string]sighting.Location)
+	locations := h.storage.Locations()
 
-	for _, s := range allSightings {
-		key := fmt.Sprintf("%s,%s", s.Location.City, s.Location.Country)
-		locationMap[key] = s.Location
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.LocationsList(locations).Render(r.Context(), w); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
+}
 
-	// Convert map to slice
-	locations := make([]sighting.Location, 0, len(locationMap))
-	for _, loc := range locationMap {
-		locations = append(locations, loc)
+// HandleAlerts renders the geofences and the alerts they have raised, newest
+// first. Accepts a "geofence" query parameter to show one geofence's alerts.
+func (h *Handler) HandleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
+	selected := r.URL.Query().Get("geofence")
+	geofences := h.geofences.List()
+	alerts := h.geofences.Alerts(selected)
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := templates.LocationsList(locations).Render(r.Context(), w); err != nil {
+	if err := templates.AlertsList(geofences, alerts, selected).Render(r.Context(), w); err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -163,10 +319,38 @@ func (h *Handler) HandleCategories(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	categories := h.registry.Categories()
+	categories := h.registry.Infos()
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := templates.CategoriesList(categories).Render(r.Context(), w); err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
+
+// HandleCategoryDetail renders the detail page for a single category.
+// Extracts the category name from the URL path (e.g., /category/kaiju -> kaiju).
+func (h *Handler) HandleCategoryDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/category/")
+	if name == "" {
+		http.Redirect(w, r, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	info, err := h.registry.Info(name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Category not found: %s", name), http.StatusNotFound)
+		return
+	}
+
+	count := len(h.storage.GetByCategory(name))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.CategoryDetail(info, count).Render(r.Context(), w); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
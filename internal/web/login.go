@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/templates"
+)
+
+// HandleLogin serves the sign-in form on GET and signs the user in on POST,
+// returning them to the page named by the "next" parameter.
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		form := templates.LoginForm{Next: r.URL.Query().Get("next")}
+		h.renderLogin(w, r, form, http.StatusOK)
+
+	case http.MethodPost:
+		if !validCSRF(r) {
+			http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+			return
+		}
+
+		form := templates.LoginForm{
+			Username: strings.TrimSpace(r.PostFormValue("username")),
+			Next:     r.PostFormValue("next"),
+		}
+		u, err := h.auth.Store().Authenticate(form.Username, r.PostFormValue("password"))
+		if err != nil {
+			form.Error = "Invalid username or password"
+			h.renderLogin(w, r, form, http.StatusUnauthorized)
+			return
+		}
+
+		h.auth.SignIn(w, r, u)
+		http.Redirect(w, r, localPath(form.Next), http.StatusSeeOther)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleLogout asks for confirmation on GET and signs the user out on POST, so
+// that another site cannot sign users out with a plain link.
+func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		token := csrfToken(w, r)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := templates.Logout(token).Render(r.Context(), w); err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+
+	case http.MethodPost:
+		if !validCSRF(r) {
+			http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+			return
+		}
+		h.auth.SignOut(w, r)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// renderLogin writes the sign-in form showing the values and error in form.
+func (h *Handler) renderLogin(w http.ResponseWriter, r *http.Request, form templates.LoginForm, status int) {
+	form.CSRF = csrfToken(w, r)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := templates.Login(form).Render(r.Context(), w); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// localPath returns next if it is a path on this site, and "/" otherwise, so the
+// login redirect cannot be used to send users elsewhere.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,216 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+	"github.com/pymk/creature-sighting/internal/templates"
+)
+
+// Limits on sightings entered by hand.
+const (
+	maxNameLength        = 100
+	maxPlaceLength       = 100
+	maxDescriptionLength = 4000
+)
+
+// timestampLayout is the format of an HTML datetime-local input.
+const timestampLayout = "2006-01-02T15:04"
+
+// HandleNewSighting serves the form for entering a sighting by hand at
+// /sighting/new. The form is filled in two steps so that it works without
+// JavaScript: GET with a "category" query parameter shows that category's types
+// and attributes, and POST stores the sighting as reported and redirects to it.
+// Rejected submissions re-render the form with an error beside each bad field.
+func (h *Handler) HandleNewSighting(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		form := templates.NewSightingForm{
+			Category:  r.URL.Query().Get("category"),
+			Source:    "catalog",
+			Timestamp: time.Now().UTC().Format(timestampLayout),
+		}
+		if _, err := h.registry.Info(form.Category); form.Category != "" && err != nil {
+			form.Errors = map[string]string{"category": fmt.Sprintf("Unknown category %q", form.Category)}
+			form.Category = ""
+		}
+		h.renderNewSighting(w, r, form, http.StatusOK)
+
+	case http.MethodPost:
+		if !validCSRF(r) {
+			http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+			return
+		}
+		h.createSighting(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createSighting validates a submitted sighting and stores it.
+func (h *Handler) createSighting(w http.ResponseWriter, r *http.Request) {
+	form := templates.NewSightingForm{
+		Category:    r.PostFormValue("category"),
+		Name:        strings.TrimSpace(r.PostFormValue("name")),
+		Type:        strings.TrimSpace(r.PostFormValue("type")),
+		Source:      r.PostFormValue("source"),
+		Place:       r.PostFormValue("place"),
+		Latitude:    strings.TrimSpace(r.PostFormValue("latitude")),
+		Longitude:   strings.TrimSpace(r.PostFormValue("longitude")),
+		City:        strings.TrimSpace(r.PostFormValue("city")),
+		Country:     strings.TrimSpace(r.PostFormValue("country")),
+		Region:      strings.TrimSpace(r.PostFormValue("region")),
+		Timestamp:   r.PostFormValue("timestamp"),
+		Description: strings.TrimSpace(r.PostFormValue("description")),
+		Attributes:  make(map[string]string),
+		Errors:      make(map[string]string),
+	}
+
+	info, err := h.registry.Info(form.Category)
+	if err != nil {
+		form.Errors["category"] = fmt.Sprintf("Unknown category %q", form.Category)
+		form.Category = ""
+		h.renderNewSighting(w, r, form, http.StatusBadRequest)
+		return
+	}
+	for _, field := range info.Attributes {
+		form.Attributes[field.Name] = r.PostFormValue("attr_" + field.Name)
+	}
+
+	s := sighting.Sighting{
+		ID:          fmt.Sprintf("%s-%d", info.Name, time.Now().UnixNano()),
+		Name:        form.Name,
+		Type:        form.Type,
+		Category:    info.Name,
+		Description: form.Description,
+		Attributes:  make(sighting.Attributes),
+		Status:      sighting.StatusReported,
+	}
+
+	switch {
+	case s.Type == "":
+		form.Errors["type"] = "Type is required"
+	case len(info.Types) > 0 && !slices.Contains(info.Types, s.Type):
+		form.Errors["type"] = fmt.Sprintf("Type must be one of %s", strings.Join(info.Types, ", "))
+	}
+
+	if len(s.Name) > maxNameLength {
+		form.Errors["name"] = fmt.Sprintf("Name must be at most %d characters", maxNameLength)
+	}
+	if s.Name == "" {
+		s.Name = "Unidentified " + s.Type
+	}
+
+	s.Location = h.parseLocation(form)
+
+	timestamp, err := time.ParseInLocation(timestampLayout, form.Timestamp, time.UTC)
+	switch {
+	case err != nil:
+		form.Errors["timestamp"] = "Timestamp must be a date and time"
+	case timestamp.After(time.Now()):
+		form.Errors["timestamp"] = "Timestamp cannot be in the future"
+	}
+	s.Timestamp = timestamp
+
+	switch {
+	case s.Description == "":
+		form.Errors["description"] = "Description is required"
+	case len(s.Description) > maxDescriptionLength:
+		form.Errors["description"] = fmt.Sprintf("Description must be at most %d characters", maxDescriptionLength)
+	}
+
+	for _, field := range info.Attributes {
+		value := form.Attributes[field.Name]
+		if field.Type == sighting.AttributeBool {
+			// Unchecked boxes are not submitted at all
+			value = strconv.FormatBool(value != "")
+		}
+		if strings.TrimSpace(value) == "" {
+			if field.Required {
+				form.Errors["attr_"+field.Name] = field.DisplayLabel() + " is required"
+			}
+			continue
+		}
+		v, err := field.Parse(value)
+		if err != nil {
+			form.Errors["attr_"+field.Name] = err.Error()
+			continue
+		}
+		s.Attributes[field.Name] = v
+	}
+
+	if len(form.Errors) == 0 {
+		if err := h.registry.Normalize(&s); err != nil {
+			form.Errors["form"] = err.Error()
+		}
+	}
+	if len(form.Errors) > 0 {
+		h.renderNewSighting(w, r, form, http.StatusBadRequest)
+		return
+	}
+
+	h.storage.Add(s)
+	http.Redirect(w, r, "/sighting/"+s.ID, http.StatusSeeOther)
+}
+
+// parseLocation returns the location chosen on the form, either a place from the
+// catalog or raw coordinates, recording any problems in form.Errors.
+func (h *Handler) parseLocation(form templates.NewSightingForm) sighting.Location {
+	if form.Source == "catalog" {
+		for _, loc := range h.catalog() {
+			if templates.PlaceKey(loc) == form.Place {
+				return loc
+			}
+		}
+		form.Errors["place"] = "Choose a place from the catalog"
+		return sighting.Location{}
+	}
+
+	lat, err := strconv.ParseFloat(form.Latitude, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		form.Errors["latitude"] = "Latitude must be a number between -90 and 90"
+	}
+	lon, err := strconv.ParseFloat(form.Longitude, 64)
+	if err != nil || lon < -180 || lon > 180 {
+		form.Errors["longitude"] = "Longitude must be a number between -180 and 180"
+	}
+	for name, value := range map[string]string{"city": form.City, "country": form.Country, "region": form.Region} {
+		if len(value) > maxPlaceLength {
+			form.Errors[name] = fmt.Sprintf("Must be at most %d characters", maxPlaceLength)
+		}
+	}
+	return sighting.Location{
+		Latitude:  lat,
+		Longitude: lon,
+		City:      form.City,
+		Country:   form.Country,
+		Region:    form.Region,
+	}
+}
+
+// catalog returns the named places sightings have been made, for the location
+// picker. Places without a city, such as positions at sea, are left out.
+func (h *Handler) catalog() []sighting.Location {
+	return slices.DeleteFunc(h.storage.Locations(), func(loc sighting.Location) bool {
+		return loc.City == ""
+	})
+}
+
+// renderNewSighting writes the new sighting form showing the values and errors in form.
+func (h *Handler) renderNewSighting(w http.ResponseWriter, r *http.Request, form templates.NewSightingForm, status int) {
+	// An empty category shows only the category picker
+	info, _ := h.registry.Info(form.Category)
+	form.CSRF = csrfToken(w, r)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := templates.NewSighting(h.registry.Infos(), info, h.catalog(), form).Render(r.Context(), w); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
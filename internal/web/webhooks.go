@@ -0,0 +1,52 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pymk/creature-sighting/internal/templates"
+	"github.com/pymk/creature-sighting/internal/webhook"
+)
+
+// HandleWebhooks renders the webhook subscriptions with their dead letters and
+// recent deliveries via GET /webhooks.
+func (h *Handler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	csrf := csrfToken(w, r)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page := templates.Webhooks(h.webhooks.Subscriptions(), h.webhooks.Deliveries(), h.webhooks.DeadLetters(), csrf)
+	if err := page.Render(r.Context(), w); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// HandleWebhookRetry queues a dead letter for redelivery via
+// POST /webhooks/dead-letters/{id}/retry, then redirects to the delivery log.
+func (h *Handler) HandleWebhookRetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validCSRF(r) {
+		http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+		return
+	}
+
+	id, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/webhooks/dead-letters/"), "/retry")
+	_, err := h.webhooks.Redeliver(id)
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		http.Error(w, "Dead letter not found", http.StatusNotFound)
+	case err != nil:
+		log.Printf("Error redelivering webhook: %v", err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusServiceUnavailable)
+	default:
+		http.Redirect(w, r, "/webhooks#deliveries", http.StatusSeeOther)
+	}
+}
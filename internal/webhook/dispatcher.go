@@ -0,0 +1,474 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// ErrClosed is reported by Check once the dispatcher has been closed.
+var ErrClosed = errors.New("webhook dispatcher closed")
+
+// Config tunes delivery. Zero fields take the defaults from DefaultConfig.
+type Config struct {
+	// Workers is the number of deliveries attempted at once.
+	Workers int
+	// MaxAttempts is how many times a delivery is tried before it is dead-lettered.
+	MaxAttempts int
+	// Backoff is the wait before the first retry; each later retry waits twice
+	// as long as the one before, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout limits each attempt, including reading the response.
+	Timeout time.Duration
+	// LogSize is how many deliveries, and separately dead letters, are kept.
+	LogSize int
+	// Client sends deliveries. It defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// DefaultConfig is used for fields left zero in the Config passed to Open.
+var DefaultConfig = Config{
+	Workers:     4,
+	MaxAttempts: 6,
+	Backoff:     2 * time.Second,
+	MaxBackoff:  5 * time.Minute,
+	Timeout:     10 * time.Second,
+	LogSize:     500,
+}
+
+// State is the progress of a delivery.
+type State string
+
+// Delivery states.
+const (
+	StatePending   State = "pending"
+	StateRetrying  State = "retrying"
+	StateDelivered State = "delivered"
+	StateDead      State = "dead"
+)
+
+// Delivery is one event sent, or being sent, to one subscription.
+type Delivery struct {
+	ID           string     `json:"id"`
+	Subscription string     `json:"subscription"`
+	URL          string     `json:"url"`
+	Event        string     `json:"event"`
+	SightingID   string     `json:"sighting_id"`
+	State        State      `json:"state"`
+	Attempts     int        `json:"attempts"`
+	LastStatus   int        `json:"last_status,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Created      time.Time  `json:"created"`
+	Updated      time.Time  `json:"updated"`
+	NextAttempt  *time.Time `json:"next_attempt,omitempty"`
+
+	secret string
+	body   []byte
+}
+
+// payload is the JSON body of a delivery.
+type payload struct {
+	ID       string            `json:"id"`
+	Event    string            `json:"event"`
+	Created  time.Time         `json:"created"`
+	Sighting sighting.Sighting `json:"sighting"`
+}
+
+// Dispatcher holds the webhook subscriptions and delivers matching sightings to
+// them in the background. Subscriptions are written through to a JSON file
+// readable only by its owner, since it holds the signing secrets.
+type Dispatcher struct {
+	config Config
+	path   string
+	queue  chan *Delivery
+
+	mu         sync.Mutex
+	subs       []Subscription
+	deliveries []*Delivery
+	dead       []*Delivery
+	closed     bool
+
+	running atomic.Int32
+	wg      sync.WaitGroup
+}
+
+// Open loads the subscriptions at path and starts the delivery workers. A
+// missing file is treated as having no subscriptions and created on the first
+// change.
+func Open(path string, config Config) (*Dispatcher, error) {
+	config = config.withDefaults()
+	d := &Dispatcher{
+		config: config,
+		path:   path,
+		queue:  make(chan *Delivery, 1024),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	default:
+		if err := json.Unmarshal(data, &d.subs); err != nil {
+			return nil, fmt.Errorf("failed to parse webhooks in %s: %w", path, err)
+		}
+	}
+
+	for range config.Workers {
+		d.wg.Add(1)
+		d.running.Add(1)
+		go d.work()
+	}
+	return d, nil
+}
+
+// withDefaults fills zero fields from DefaultConfig.
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = DefaultConfig.Workers
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultConfig.MaxAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultConfig.Backoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultConfig.MaxBackoff
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultConfig.Timeout
+	}
+	if c.LogSize <= 0 {
+		c.LogSize = DefaultConfig.LogSize
+	}
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	return c
+}
+
+// Subscribe adds a subscription for url, returning it with its new signing secret.
+func (d *Dispatcher) Subscribe(url, owner string, filter Filter) (Subscription, error) {
+	if err := checkURL(url); err != nil {
+		return Subscription{}, err
+	}
+	if err := filter.Check(); err != nil {
+		return Subscription{}, err
+	}
+	if filter.MinThreat != "" {
+		filter.MinThreat, _ = sighting.ParseThreat(string(filter.MinThreat))
+	}
+
+	sub := Subscription{
+		ID:      newID(8),
+		URL:     url,
+		Secret:  newSecret(),
+		Filter:  filter,
+		Owner:   owner,
+		Created: time.Now().UTC(),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subs = append(d.subs, sub)
+	if err := d.save(); err != nil {
+		d.subs = d.subs[:len(d.subs)-1]
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes the subscription with the given ID and returns it.
+// Deliveries already queued for it are still attempted.
+func (d *Dispatcher) Unsubscribe(id string) (Subscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := slices.IndexFunc(d.subs, func(s Subscription) bool { return s.ID == id })
+	if i < 0 {
+		return Subscription{}, fmt.Errorf("%w: webhook %s", ErrNotFound, id)
+	}
+	sub := d.subs[i]
+	previous := d.subs
+	d.subs = slices.Delete(slices.Clone(d.subs), i, i+1)
+	if err := d.save(); err != nil {
+		d.subs = previous
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Subscriptions returns every subscription, in the order they were added.
+func (d *Dispatcher) Subscriptions() []Subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.subs)
+}
+
+// Notify queues a delivery of s to every subscription whose filter matches it.
+// It does not wait for the deliveries, so it is safe to register with
+// storage.Store.OnAdd.
+func (d *Dispatcher) Notify(s sighting.Sighting) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, sub := range d.subs {
+		if !sub.Filter.Matches(s) {
+			continue
+		}
+		delivery := &Delivery{
+			ID:           newID(8),
+			Subscription: sub.ID,
+			URL:          sub.URL,
+			Event:        EventSightingCreated,
+			SightingID:   s.ID,
+			State:        StatePending,
+			Created:      now,
+			Updated:      now,
+			secret:       sub.Secret,
+		}
+		body, err := json.Marshal(payload{ID: delivery.ID, Event: delivery.Event, Created: now, Sighting: s})
+		if err != nil {
+			log.Printf("Failed to encode webhook payload for sighting %s: %v", s.ID, err)
+			return
+		}
+		delivery.body = body
+
+		d.deliveries = append(d.deliveries, delivery)
+		if over := len(d.deliveries) - d.config.LogSize; over > 0 {
+			d.deliveries = slices.Delete(d.deliveries, 0, over)
+		}
+		d.enqueue(delivery)
+	}
+}
+
+// Deliveries returns the most recent deliveries, newest first.
+func (d *Dispatcher) Deliveries() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return snapshot(d.deliveries)
+}
+
+// DeadLetters returns the deliveries that ran out of attempts, newest first.
+func (d *Dispatcher) DeadLetters() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return snapshot(d.dead)
+}
+
+// Redeliver takes the dead letter with the given ID off the dead-letter list and
+// queues it again with a fresh set of attempts.
+func (d *Dispatcher) Redeliver(id string) (Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return Delivery{}, ErrClosed
+	}
+
+	i := slices.IndexFunc(d.dead, func(dl *Delivery) bool { return dl.ID == id })
+	if i < 0 {
+		return Delivery{}, fmt.Errorf("%w: dead letter %s", ErrNotFound, id)
+	}
+	delivery := d.dead[i]
+	d.dead = slices.Delete(d.dead, i, i+1)
+
+	delivery.State, delivery.Attempts, delivery.Updated = StatePending, 0, time.Now().UTC()
+	if !slices.Contains(d.deliveries, delivery) {
+		d.deliveries = append(d.deliveries, delivery)
+		if over := len(d.deliveries) - d.config.LogSize; over > 0 {
+			d.deliveries = slices.Delete(d.deliveries, 0, over)
+		}
+	}
+	d.enqueue(delivery)
+	return *delivery, nil
+}
+
+// Check reports whether every delivery worker is running.
+func (d *Dispatcher) Check() error {
+	d.mu.Lock()
+	closed := d.closed
+	d.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if n := int(d.running.Load()); n < d.config.Workers {
+		return fmt.Errorf("%d of %d delivery workers running", n, d.config.Workers)
+	}
+	return nil
+}
+
+// Close stops the delivery workers once they finish their current attempts.
+// Queued deliveries and pending retries are abandoned.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// enqueue hands a delivery to the workers, retrying shortly if the queue is
+// full so that Notify never blocks. The caller must hold the lock.
+func (d *Dispatcher) enqueue(delivery *Delivery) {
+	if d.closed {
+		return
+	}
+	select {
+	case d.queue <- delivery:
+	default:
+		d.schedule(delivery, d.config.Backoff)
+	}
+}
+
+// schedule queues a delivery again after wait. The caller must hold the lock.
+func (d *Dispatcher) schedule(delivery *Delivery, wait time.Duration) {
+	next := time.Now().Add(wait).UTC()
+	delivery.NextAttempt = &next
+	time.AfterFunc(wait, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delivery.NextAttempt = nil
+		d.enqueue(delivery)
+	})
+}
+
+// work attempts queued deliveries until the queue is closed.
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	defer d.running.Add(-1)
+	for delivery := range d.queue {
+		d.attempt(delivery)
+	}
+}
+
+// attempt sends a delivery once and records the outcome, scheduling a retry or
+// dead-lettering it on failure.
+func (d *Dispatcher) attempt(delivery *Delivery) {
+	status, err := d.send(delivery)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delivery.Attempts++
+	delivery.LastStatus = status
+	delivery.Updated = time.Now().UTC()
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.State = StateDelivered
+	case delivery.Attempts >= d.config.MaxAttempts:
+		delivery.State, delivery.LastError = StateDead, err.Error()
+		d.dead = append(d.dead, delivery)
+		if over := len(d.dead) - d.config.LogSize; over > 0 {
+			d.dead = slices.Delete(d.dead, 0, over)
+		}
+		log.Printf("Webhook delivery %s to %s dead-lettered after %d attempts: %v", delivery.ID, delivery.URL, delivery.Attempts, err)
+	default:
+		delivery.State, delivery.LastError = StateRetrying, err.Error()
+		d.schedule(delivery, d.backoff(delivery.Attempts))
+	}
+}
+
+// send POSTs a delivery's payload, signed with the current time, and returns the
+// response status. Any status outside 2xx is an error.
+func (d *Dispatcher) send(delivery *Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "creature-sighting-webhook/1")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.secret, timestamp, delivery.body))
+
+	resp, err := d.config.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before the retry that follows the given number of
+// attempts: Backoff doubled for each earlier retry, capped at MaxBackoff, with up
+// to a fifth taken off at random so that failed deliveries spread out.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.MaxBackoff
+	if shift := attempts - 1; shift < 30 {
+		wait = min(d.config.Backoff<<shift, d.config.MaxBackoff)
+	}
+	return wait - time.Duration(rand.Int64N(int64(wait)/5+1))
+}
+
+// save writes the subscriptions to their file, replacing it atomically. The
+// caller must hold the lock.
+func (d *Dispatcher) save() error {
+	data, err := json.MarshalIndent(d.subs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), ".webhooks-*")
+	if err != nil {
+		return fmt.Errorf("failed to save webhooks: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save webhooks: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save webhooks: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path); err != nil {
+		return fmt.Errorf("failed to save webhooks: %w", err)
+	}
+	return nil
+}
+
+// snapshot copies deliveries, newest first.
+func snapshot(deliveries []*Delivery) []Delivery {
+	out := make([]Delivery, len(deliveries))
+	for i, delivery := range deliveries {
+		out[len(out)-1-i] = *delivery
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// testConfig retries quickly so that tests run in milliseconds.
+var testConfig = Config{
+	Workers:     2,
+	MaxAttempts: 3,
+	Backoff:     10 * time.Millisecond,
+	MaxBackoff:  40 * time.Millisecond,
+	Timeout:     time.Second,
+}
+
+// received is a request captured by a test receiver.
+type received struct {
+	header http.Header
+	body   []byte
+	at     time.Time
+}
+
+// receiver is a local webhook endpoint that records every request and answers
+// with the status returned by respond.
+type receiver struct {
+	*httptest.Server
+	respond func(n int) int // status for the nth request, counting from 1
+
+	mu       sync.Mutex
+	requests []received
+}
+
+// newReceiver starts a receiver that is shut down when the test ends.
+func newReceiver(t *testing.T, respond func(n int) int) *receiver {
+	t.Helper()
+	rcv := &receiver{respond: respond}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		rcv.requests = append(rcv.requests, received{r.Header.Clone(), body, time.Now()})
+		n := len(rcv.requests)
+		rcv.mu.Unlock()
+		w.WriteHeader(rcv.respond(n))
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+// Requests returns the requests received so far.
+func (rcv *receiver) Requests() []received {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]received(nil), rcv.requests...)
+}
+
+// openDispatcher opens a dispatcher backed by a temporary file and closes it when
+// the test ends.
+func openDispatcher(t *testing.T, config Config) *Dispatcher {
+	t.Helper()
+	d, err := Open(filepath.Join(t.TempDir(), "webhooks.json"), config)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(d.Close)
+	return d
+}
+
+// subscribe subscribes url to every sighting.
+func subscribe(t *testing.T, d *Dispatcher, url string) Subscription {
+	t.Helper()
+	sub, err := d.Subscribe(url, "tester", Filter{})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	return sub
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// onlyDelivery returns the dispatcher's single delivery.
+func onlyDelivery(t *testing.T, d *Dispatcher) Delivery {
+	t.Helper()
+	deliveries := d.Deliveries()
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+// testSighting returns a sighting that matches an empty filter.
+func testSighting() sighting.Sighting {
+	return sighting.Sighting{
+		ID:       "s-1",
+		Name:     "Nessie",
+		Category: "marine",
+		Location: sighting.Location{Latitude: 57.3, Longitude: -4.45, Region: "Highlands"},
+		Threat:   sighting.ThreatLow,
+	}
+}
+
+func TestDeliverySigned(t *testing.T) {
+	rcv := newReceiver(t, func(int) int { return http.StatusNoContent })
+	d := openDispatcher(t, testConfig)
+	sub := subscribe(t, d, rcv.URL)
+
+	d.Notify(testSighting())
+	waitFor(t, "delivery", func() bool { return onlyDelivery(t, d).State == StateDelivered })
+
+	delivery := onlyDelivery(t, d)
+	if delivery.Attempts != 1 || delivery.LastStatus != http.StatusNoContent {
+		t.Errorf("delivery made %d attempts with status %d, want 1 with 204", delivery.Attempts, delivery.LastStatus)
+	}
+
+	requests := rcv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if got := req.header.Get(EventHeader); got != EventSightingCreated {
+		t.Errorf("%s = %q, want %q", EventHeader, got, EventSightingCreated)
+	}
+	if got := req.header.Get(DeliveryHeader); got != delivery.ID {
+		t.Errorf("%s = %q, want %q", DeliveryHeader, got, delivery.ID)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if err := Verify(sub.Secret, req.header.Get(TimestampHeader), req.header.Get(SignatureHeader), req.body, time.Minute); err != nil {
+		t.Errorf("Verify with the subscription secret: %v", err)
+	}
+	if err := Verify("whsec_other", req.header.Get(TimestampHeader), req.header.Get(SignatureHeader), req.body, time.Minute); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify with another secret = %v, want ErrBadSignature", err)
+	}
+
+	var p payload
+	if err := json.Unmarshal(req.body, &p); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if p.ID != delivery.ID || p.Event != EventSightingCreated || p.Sighting.ID != "s-1" || p.Sighting.Name != "Nessie" {
+		t.Errorf("payload = %+v, want delivery %s of sighting s-1", p, delivery.ID)
+	}
+}
+
+func TestDeliveryFiltered(t *testing.T) {
+	rcv := newReceiver(t, func(int) int { return http.StatusOK })
+	d := openDispatcher(t, testConfig)
+	if _, err := d.Subscribe(rcv.URL, "tester", Filter{Categories: []string{"kaiju"}}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	d.Notify(testSighting())
+	if got := d.Deliveries(); len(got) != 0 {
+		t.Errorf("got %d deliveries for a sighting outside the filter, want 0", len(got))
+	}
+}
+
+func TestDeliveryRetriedWithBackoff(t *testing.T) {
+	rcv := newReceiver(t, func(n int) int {
+		if n < 3 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	d := openDispatcher(t, Config{
+		Workers:     1,
+		MaxAttempts: 5,
+		Backoff:     20 * time.Millisecond,
+		MaxBackoff:  time.Second,
+		Timeout:     time.Second,
+	})
+	subscribe(t, d, rcv.URL)
+
+	d.Notify(testSighting())
+	waitFor(t, "delivery", func() bool { return onlyDelivery(t, d).State == StateDelivered })
+
+	delivery := onlyDelivery(t, d)
+	if delivery.Attempts != 3 || delivery.LastError != "" {
+		t.Errorf("delivery made %d attempts with error %q, want 3 and none", delivery.Attempts, delivery.LastError)
+	}
+	if got := d.DeadLetters(); len(got) != 0 {
+		t.Errorf("got %d dead letters, want 0", len(got))
+	}
+
+	requests := rcv.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(requests))
+	}
+	// Each wait doubles, less up to a fifth of jitter: at least 16ms, then 32ms.
+	for i, least := range []time.Duration{16 * time.Millisecond, 32 * time.Millisecond} {
+		if gap := requests[i+1].at.Sub(requests[i].at); gap < least {
+			t.Errorf("retry %d came after %v, want at least %v", i+1, gap, least)
+		}
+	}
+	if requests[0].header.Get(DeliveryHeader) != requests[2].header.Get(DeliveryHeader) {
+		t.Error("retries were sent with a different delivery ID")
+	}
+}
+
+func TestDeadLetterRedelivered(t *testing.T) {
+	var healthy atomic.Bool
+	rcv := newReceiver(t, func(int) int {
+		if healthy.Load() {
+			return http.StatusOK
+		}
+		return http.StatusInternalServerError
+	})
+	d := openDispatcher(t, testConfig)
+	subscribe(t, d, rcv.URL)
+
+	d.Notify(testSighting())
+	waitFor(t, "dead letter", func() bool { return len(d.DeadLetters()) == 1 })
+
+	dead := d.DeadLetters()[0]
+	if dead.State != StateDead || dead.Attempts != testConfig.MaxAttempts || dead.LastStatus != http.StatusInternalServerError || dead.LastError == "" {
+		t.Errorf("dead letter = %+v, want dead after %d attempts with status 500", dead, testConfig.MaxAttempts)
+	}
+	if got := len(rcv.Requests()); got != testConfig.MaxAttempts {
+		t.Errorf("receiver got %d requests, want %d", got, testConfig.MaxAttempts)
+	}
+
+	healthy.Store(true)
+	queued, err := d.Redeliver(dead.ID)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if queued.State != StatePending || queued.Attempts != 0 {
+		t.Errorf("redelivered delivery is %s with %d attempts, want pending with 0", queued.State, queued.Attempts)
+	}
+	if got := d.DeadLetters(); len(got) != 0 {
+		t.Errorf("got %d dead letters after Redeliver, want 0", len(got))
+	}
+
+	waitFor(t, "redelivery", func() bool { return onlyDelivery(t, d).State == StateDelivered })
+	if got := onlyDelivery(t, d); got.ID != dead.ID || got.Attempts != 1 {
+		t.Errorf("redelivery is %s after %d attempts, want %s after 1", got.ID, got.Attempts, dead.ID)
+	}
+
+	if _, err := d.Redeliver(dead.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Redeliver = %v, want ErrNotFound", err)
+	}
+}
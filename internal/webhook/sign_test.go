@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"sighting.created"}`)
+	now := time.Now().Unix()
+	signature := Sign("whsec_test", now, body)
+
+	if err := Verify("whsec_test", strconv.FormatInt(now, 10), signature, body, time.Minute); err != nil {
+		t.Fatalf("Verify of a fresh signature: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+	}{
+		{"wrong secret", "whsec_other", strconv.FormatInt(now, 10), body},
+		{"altered body", "whsec_test", strconv.FormatInt(now, 10), []byte(`{"event":"sighting.deleted"}`)},
+		{"altered timestamp", "whsec_test", strconv.FormatInt(now+1, 10), body},
+		{"malformed timestamp", "whsec_test", "yesterday", body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.timestamp, signature, tt.body, time.Minute); !errors.Is(err, ErrBadSignature) {
+				t.Errorf("Verify = %v, want ErrBadSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsStaleSignature(t *testing.T) {
+	body := []byte(`{}`)
+	old := time.Now().Add(-10 * time.Minute).Unix()
+
+	err := Verify("whsec_test", strconv.FormatInt(old, 10), Sign("whsec_test", old, body), body, 5*time.Minute)
+	if !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify of a signature 10 minutes old = %v, want ErrBadSignature", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package webhook notifies downstream services of new sightings. Services
+// subscribe a URL with a filter; every stored sighting that matches is POSTed to
+// it as signed JSON. Failed deliveries are retried with exponential backoff and,
+// once attempts run out, kept on a dead-letter list from which they can be
+// redelivered. Subscriptions are saved to a file; deliveries live in memory.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pymk/creature-sighting/internal/sighting"
+)
+
+// Errors returned when managing subscriptions and deliveries.
+var (
+	ErrInvalidSubscription = errors.New("invalid webhook subscription")
+	ErrNotFound            = errors.New("not found")
+)
+
+// EventSightingCreated is sent when a sighting is stored.
+const EventSightingCreated = "sighting.created"
+
+// Subscription is a URL that receives the sightings matching its filter. The
+// secret signs every delivery so the receiver can check it came from us.
+type Subscription struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret"`
+	Filter  Filter    `json:"filter"`
+	Owner   string    `json:"owner"`
+	Created time.Time `json:"created"`
+}
+
+// Filter selects the sightings a subscription receives. Every set criterion must
+// match; an empty filter matches every sighting.
+type Filter struct {
+	Categories []string        `json:"categories,omitempty"`
+	Regions    []string        `json:"regions,omitempty"`
+	MinThreat  sighting.Threat `json:"min_threat,omitempty"`
+	BBox       *BBox           `json:"bbox,omitempty"`
+}
+
+// BBox is a latitude and longitude bounding box, inclusive of its edges. A box
+// whose West edge is east of its East edge crosses the antimeridian.
+type BBox struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
+}
+
+// Matches reports whether s passes every criterion of the filter. Regions are
+// compared ignoring case, and sightings with no threat assessment fail a
+// minimum threat.
+func (f Filter) Matches(s sighting.Sighting) bool {
+	if len(f.Categories) > 0 && !slices.Contains(f.Categories, s.Category) {
+		return false
+	}
+	if len(f.Regions) > 0 && !slices.ContainsFunc(f.Regions, func(r string) bool { return strings.EqualFold(r, s.Location.Region) }) {
+		return false
+	}
+	if f.MinThreat != "" && !s.Threat.AtLeast(f.MinThreat) {
+		return false
+	}
+	if f.BBox != nil && !f.BBox.Contains(s.Location.Latitude, s.Location.Longitude) {
+		return false
+	}
+	return true
+}
+
+// Check verifies that the filter's threat level and bounding box are well formed.
+func (f Filter) Check() error {
+	if f.MinThreat != "" {
+		if _, err := sighting.ParseThreat(string(f.MinThreat)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+		}
+	}
+	if f.BBox != nil {
+		return f.BBox.Check()
+	}
+	return nil
+}
+
+// Contains reports whether the point lies within the box.
+func (b BBox) Contains(lat, lon float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return lon >= b.West && lon <= b.East
+	}
+	return lon >= b.West || lon <= b.East
+}
+
+// Check verifies that the box lies on the globe and its South edge is not north
+// of its North edge.
+func (b BBox) Check() error {
+	switch {
+	case b.South < -90 || b.North > 90 || b.South > b.North:
+		return fmt.Errorf("%w: bounding box latitudes must satisfy -90 <= south <= north <= 90", ErrInvalidSubscription)
+	case b.West < -180 || b.West > 180 || b.East < -180 || b.East > 180:
+		return fmt.Errorf("%w: bounding box longitudes must be between -180 and 180", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// checkURL verifies that raw is an absolute http or https URL.
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// newID returns a random identifier of n bytes in hex.
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newSecret returns a random signing secret.
+func newSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b)
+}
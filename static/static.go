@@ -0,0 +1,10 @@
+// Package static embeds the site's stylesheets, scripts and images so the server
+// binary can run from any directory. Add a pattern below for each new asset type.
+package static
+
+import "embed"
+
+// FS holds the embedded assets, named relative to this directory.
+//
+//go:embed *.css
+var FS embed.FS